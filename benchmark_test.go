@@ -68,3 +68,50 @@ func BenchmarkRouter(b *testing.B) {
 		app.ServeHTTP(w, req)
 	}
 }
+
+// benchmarkRoutes returns a realistic API route table of several hundred
+// routes with long shared static prefixes, and request paths hitting it.
+func benchmarkRoutes() (routes []string, paths []string) {
+	resources := []string{
+		"users", "orders", "products", "invoices", "customers", "payments",
+		"shipments", "warehouses", "suppliers", "categories", "reviews", "coupons",
+		"subscriptions", "plans", "teams", "projects", "tasks", "webhooks",
+	}
+	for _, version := range []string{"v1", "v2"} {
+		for _, res := range resources {
+			base := "/api/" + version + "/" + res
+			routes = append(routes,
+				base,
+				base+"/:id",
+				base+"/:id/history",
+				base+"/:id/comments",
+				base+"/:id/comments/:commentID",
+				base+"/search/advanced",
+				"/api/"+version+"/admin/settings/"+res+"/notifications/email",
+				"/api/"+version+"/admin/settings/"+res+"/notifications/sms",
+			)
+			paths = append(paths,
+				base,
+				base+"/42/comments/7",
+				"/api/"+version+"/admin/settings/"+res+"/notifications/email",
+			)
+		}
+	}
+	routes = append(routes, "/static/*filepath", "/health", "/")
+	paths = append(paths, "/static/css/app/main.css", "/health", "/missing/route")
+	return routes, paths
+}
+
+func BenchmarkRouterLookup(b *testing.B) {
+	routes, paths := benchmarkRoutes()
+	r := newRouter()
+	for _, route := range routes {
+		r.addRoute("GET", route, func(c *Context) error { return nil })
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.getRoute("GET", paths[i%len(paths)])
+	}
+}
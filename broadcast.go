@@ -0,0 +1,227 @@
+package ginji
+
+import (
+	"sync"
+	"time"
+)
+
+// DropPolicy decides what Broadcaster.Publish does when a subscriber's
+// buffer is full.
+type DropPolicy int
+
+const (
+	// DropNewest discards the event being published for that subscriber.
+	DropNewest DropPolicy = iota
+	// DropOldest discards the subscriber's oldest buffered event to make room.
+	DropOldest
+	// Block waits up to BroadcasterConfig.BlockTimeout for room, then
+	// discards the event.
+	Block
+)
+
+// BroadcasterConfig defines the configuration for a Broadcaster.
+type BroadcasterConfig struct {
+	// BufferSize is the number of events buffered per subscriber.
+	// Default: 16
+	BufferSize int
+
+	// Policy handles slow subscribers whose buffer is full.
+	// Default: DropNewest
+	Policy DropPolicy
+
+	// BlockTimeout bounds how long Publish waits on a full buffer with the
+	// Block policy.
+	// Default: 1 second
+	BlockTimeout time.Duration
+}
+
+// Broadcaster fans out typed events to subscribers, each with its own
+// buffered channel. It can serve subscribers over SSE or WebSocket:
+//
+//	events := ginji.NewBroadcaster[Notification](ginji.BroadcasterConfig{Policy: ginji.DropOldest})
+//	app.Get("/events", events.ServeSSE)
+//	events.Publish(Notification{Text: "deployed"})
+type Broadcaster[T any] struct {
+	mu     sync.RWMutex
+	config BroadcasterConfig
+	subs   map[<-chan T]*subscriber[T]
+	closed bool
+}
+
+// subscriber is a subscription channel. mu serializes sends with closing
+// ch; done is closed first so that a send blocked on a full buffer gives
+// up instead of holding up the close.
+type subscriber[T any] struct {
+	mu     sync.Mutex
+	ch     chan T
+	done   chan struct{}
+	closed bool
+}
+
+// close closes the subscription channel once any send in progress returns.
+func (s *subscriber[T]) close() {
+	close(s.done)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.ch)
+}
+
+// NewBroadcaster creates a Broadcaster with the given configuration.
+func NewBroadcaster[T any](config BroadcasterConfig) *Broadcaster[T] {
+	if config.BufferSize <= 0 {
+		config.BufferSize = 16
+	}
+	if config.BlockTimeout <= 0 {
+		config.BlockTimeout = time.Second
+	}
+	return &Broadcaster[T]{
+		config: config,
+		subs:   make(map[<-chan T]*subscriber[T]),
+	}
+}
+
+// Subscribe returns a channel that receives published events until it is
+// unsubscribed or the broadcaster is closed.
+func (b *Broadcaster[T]) Subscribe() <-chan T {
+	ch := make(chan T, b.config.BufferSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subs[ch] = &subscriber[T]{ch: ch, done: make(chan struct{})}
+	return ch
+}
+
+// Unsubscribe stops delivering events to ch and closes it.
+func (b *Broadcaster[T]) Unsubscribe(ch <-chan T) {
+	b.mu.Lock()
+	sub, ok := b.subs[ch]
+	delete(b.subs, ch)
+	b.mu.Unlock()
+
+	if ok {
+		sub.close()
+	}
+}
+
+// Publish sends event to every subscriber, applying the drop policy to
+// subscribers whose buffer is full. With the Block policy, Publish waits
+// at most BlockTimeout in total, however many subscribers are slow.
+func (b *Broadcaster[T]) Publish(event T) {
+	// Send outside the lock so slow subscribers do not hold up Subscribe,
+	// Unsubscribe and Close
+	b.mu.RLock()
+	subs := make([]*subscriber[T], 0, len(b.subs))
+	for _, sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+
+	deadline := time.Now().Add(b.config.BlockTimeout)
+	for _, sub := range subs {
+		b.send(sub, event, deadline)
+	}
+}
+
+// send delivers event to sub unless it is closed, waiting on a full buffer
+// until deadline with the Block policy.
+func (b *Broadcaster[T]) send(sub *subscriber[T], event T, deadline time.Time) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if sub.closed {
+		return
+	}
+
+	select {
+	case sub.ch <- event:
+		return
+	default:
+	}
+
+	switch b.config.Policy {
+	case DropOldest:
+		select {
+		case <-sub.ch:
+		default:
+		}
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	case Block:
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case sub.ch <- event:
+		case <-timer.C:
+		case <-sub.done:
+		}
+	}
+}
+
+// Count returns the number of subscribers.
+func (b *Broadcaster[T]) Count() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+// Close unsubscribes everyone. Later subscriptions receive a closed channel.
+func (b *Broadcaster[T]) Close() {
+	b.mu.Lock()
+	b.closed = true
+	subs := b.subs
+	b.subs = make(map[<-chan T]*subscriber[T])
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.close()
+	}
+}
+
+// ServeSSE streams events to the client as JSON-encoded SSE events until
+// the client disconnects or the broadcaster is closed.
+func (b *Broadcaster[T]) ServeSSE(c *Context) error {
+	stream := NewSSEStream(c)
+	stream.StartKeepAlive()
+	defer stream.StopKeepAlive()
+
+	events := b.Subscribe()
+	defer b.Unsubscribe(events)
+
+	for {
+		select {
+		case <-stream.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.SendJSON(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ServeWebSocket writes events to ws as JSON text messages until a write
+// fails or the broadcaster is closed.
+func (b *Broadcaster[T]) ServeWebSocket(ws *WebSocketConn) error {
+	events := b.Subscribe()
+	defer b.Unsubscribe(events)
+
+	for event := range events {
+		if err := ws.WriteJSON(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
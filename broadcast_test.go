@@ -0,0 +1,162 @@
+package ginji
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBroadcasterPublishSubscribe(t *testing.T) {
+	b := NewBroadcaster[int](BroadcasterConfig{})
+	first := b.Subscribe()
+	second := b.Subscribe()
+
+	b.Publish(1)
+	if got := <-first; got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+	if got := <-second; got != 1 {
+		t.Errorf("Expected 1, got %d", got)
+	}
+
+	b.Unsubscribe(first)
+	if _, ok := <-first; ok {
+		t.Error("Expected unsubscribed channel to be closed")
+	}
+	if b.Count() != 1 {
+		t.Errorf("Expected 1 subscriber, got %d", b.Count())
+	}
+
+	b.Close()
+	if _, ok := <-second; ok {
+		t.Error("Expected channel to be closed by Close")
+	}
+	if _, ok := <-b.Subscribe(); ok {
+		t.Error("Expected subscribing to a closed broadcaster to return a closed channel")
+	}
+}
+
+func TestBroadcasterDropPolicies(t *testing.T) {
+	drain := func(ch <-chan int) []int {
+		var got []int
+		for {
+			select {
+			case v := <-ch:
+				got = append(got, v)
+			default:
+				return got
+			}
+		}
+	}
+
+	tests := []struct {
+		policy   DropPolicy
+		expected []int
+	}{
+		{DropNewest, []int{1, 2}},
+		{DropOldest, []int{2, 3}},
+		{Block, []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		b := NewBroadcaster[int](BroadcasterConfig{BufferSize: 2, Policy: tt.policy, BlockTimeout: 10 * time.Millisecond})
+		ch := b.Subscribe()
+
+		start := time.Now()
+		for i := 1; i <= 3; i++ {
+			b.Publish(i)
+		}
+		if tt.policy == Block && time.Since(start) < 10*time.Millisecond {
+			t.Error("Expected Block policy to wait for the timeout")
+		}
+
+		got := drain(ch)
+		if len(got) != len(tt.expected) || got[0] != tt.expected[0] || got[1] != tt.expected[1] {
+			t.Errorf("Policy %d: expected %v, got %v", tt.policy, tt.expected, got)
+		}
+	}
+
+	// Block delivers once the subscriber catches up
+	b := NewBroadcaster[int](BroadcasterConfig{BufferSize: 1, Policy: Block, BlockTimeout: time.Second})
+	ch := b.Subscribe()
+	b.Publish(1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-ch
+	}()
+	b.Publish(2)
+	if got := <-ch; got != 2 {
+		t.Errorf("Expected blocked event to be delivered, got %d", got)
+	}
+}
+
+func TestBroadcasterSlowSubscriberDoesNotBlock(t *testing.T) {
+	b := NewBroadcaster[int](BroadcasterConfig{BufferSize: 1, Policy: Block, BlockTimeout: time.Second})
+	slow := b.Subscribe()
+	other := b.Subscribe()
+	b.Publish(1)
+
+	published := make(chan struct{})
+	go func() {
+		b.Publish(2)
+		close(published)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// Subscriptions are not held up by the blocked Publish
+	start := time.Now()
+	extra := b.Subscribe()
+	b.Unsubscribe(extra)
+	if b.Count() != 2 || time.Since(start) > 100*time.Millisecond {
+		t.Errorf("Expected Subscribe and Unsubscribe not to wait for Publish, took %v", time.Since(start))
+	}
+
+	// Unsubscribing the slow subscribers releases the blocked Publish
+	b.Unsubscribe(slow)
+	b.Unsubscribe(other)
+	select {
+	case <-published:
+	case <-time.After(500 * time.Millisecond):
+		t.Error("Expected Unsubscribe to release the blocked Publish")
+	}
+}
+
+func TestBroadcasterServeSSE(t *testing.T) {
+	type message struct {
+		Text string `json:"text"`
+	}
+	b := NewBroadcaster[message](BroadcasterConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	c := NewTestContext(w, req)
+
+	done := make(chan error)
+	go func() {
+		done <- b.ServeSSE(c)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for b.Count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	b.Publish(message{Text: "hello"})
+	b.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ServeSSE did not return after Close")
+	}
+	cancel()
+
+	if !strings.Contains(w.Body.String(), `data: {"text":"hello"}`) {
+		t.Errorf("Expected JSON event in stream, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,396 @@
+package ginji
+
+import (
+	"bytes"
+	"container/list"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a response stored by the Cache middleware.
+type CachedResponse struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	StoredAt time.Time
+}
+
+// CacheStore stores cached responses. Implementations must be safe for
+// concurrent use.
+type CacheStore interface {
+	// Get returns the response stored under key, if present and not expired.
+	Get(key string) (*CachedResponse, bool)
+	// Set stores a response under key for the given TTL.
+	Set(key string, resp *CachedResponse, ttl time.Duration)
+}
+
+// ResponseCacheConfig defines the configuration for the Cache middleware.
+// CacheConfig is taken by the Cache-Control header helpers.
+type ResponseCacheConfig struct {
+	// TTL is how long responses are cached.
+	// Default: 1 minute
+	TTL time.Duration
+
+	// Store holds cached responses.
+	// Default: in-memory LRU store with MaxEntries entries
+	Store CacheStore
+
+	// MaxEntries bounds the default in-memory store.
+	// Default: 1000
+	MaxEntries int
+
+	// VaryHeaders lists request headers whose values are part of the cache
+	// key, e.g. "Accept-Language" or "Authorization".
+	VaryHeaders []string
+}
+
+// Cache returns a middleware that caches successful GET responses.
+// Responses are keyed by path, query, and the configured Vary headers.
+// Cache hits are served with an Age header without calling the handler.
+// Only headers set after Cache runs are stored, so headers of outer
+// middleware such as X-Request-ID are never replayed. Handlers can opt out
+// by setting "Cache-Control: no-store" or "private"; responses setting
+// cookies are never cached. Neither are responses that the handlers make
+// vary on a request header missing from VaryHeaders, such as the
+// "Vary: Accept-Encoding" of a Compress middleware placed after Cache,
+// since one client's variant would be replayed to all.
+func Cache(config ResponseCacheConfig) Middleware {
+	if config.TTL <= 0 {
+		config.TTL = time.Minute
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 1000
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryCacheStore(config.MaxEntries)
+	}
+
+	return func(c *Context) error {
+		if c.Req.Method != http.MethodGet {
+			return c.Next()
+		}
+
+		key := cacheKey(c.Req, config.VaryHeaders)
+		c.AddVary(config.VaryHeaders...)
+		if cached, ok := config.Store.Get(key); ok {
+			replayHeader(c.Res.Header(), cached.Header)
+			age := int(time.Since(cached.StoredAt).Seconds())
+			c.SetHeader("Age", strconv.Itoa(age))
+			c.Res.WriteHeader(cached.Status)
+			c.Abort()
+			_, err := c.Res.Write(cached.Body)
+			return err
+		}
+
+		// Capture the response while writing it through
+		before := c.Res.Header().Clone()
+		originalRes := c.Res
+		cw := &cacheResponseWriter{ResponseWriter: originalRes, status: http.StatusOK}
+		c.Res = cw
+		err := c.Next()
+		c.Res = originalRes
+
+		if err != nil || cw.status != http.StatusOK || !isCacheable(cw.Header()) ||
+			!varyCovered(before, cw.Header(), config.VaryHeaders) {
+			return err
+		}
+		config.Store.Set(key, &CachedResponse{
+			Status:   cw.status,
+			Header:   headerChanges(before, cw.Header()),
+			Body:     cw.body.Bytes(),
+			StoredAt: time.Now(),
+		}, config.TTL)
+		return nil
+	}
+}
+
+// cacheKey builds the cache key from the request URL and Vary headers.
+func cacheKey(req *http.Request, varyHeaders []string) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte(' ')
+	b.WriteString(req.URL.Path)
+	if req.URL.RawQuery != "" {
+		b.WriteByte('?')
+		b.WriteString(req.URL.RawQuery)
+	}
+	for _, name := range varyHeaders {
+		b.WriteString("\n")
+		b.WriteString(http.CanonicalHeaderKey(name))
+		b.WriteByte(':')
+		b.WriteString(req.Header.Get(name))
+	}
+	return b.String()
+}
+
+// isCacheable reports whether the response headers allow shared caching.
+// Responses setting cookies belong to one client and are never shared.
+func isCacheable(header http.Header) bool {
+	if len(header.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	cc := strings.ToLower(header.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
+
+// varyCovered reports whether every Vary value added to after since before
+// is one of the keyed varyHeaders. "Vary: *" is never covered.
+func varyCovered(before, after http.Header, varyHeaders []string) bool {
+	existing := varyValues(before)
+	keyed := make(map[string]bool, len(varyHeaders))
+	for _, name := range varyHeaders {
+		keyed[http.CanonicalHeaderKey(name)] = true
+	}
+	for name := range varyValues(after) {
+		if name == "*" || (!existing[name] && !keyed[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// varyValues returns the header names listed in the Vary header of h.
+func varyValues(h http.Header) map[string]bool {
+	names := make(map[string]bool)
+	for _, line := range h.Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names[http.CanonicalHeaderKey(name)] = true
+			}
+		}
+	}
+	return names
+}
+
+// headerChanges returns the headers of after that are new or changed
+// compared to before, i.e. those written by the wrapped handlers.
+func headerChanges(before, after http.Header) http.Header {
+	changed := make(http.Header)
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			changed[name] = slices.Clone(values)
+		}
+	}
+	return changed
+}
+
+// replayHeader sets the stored headers on header, replacing current values.
+func replayHeader(header, stored http.Header) {
+	for name, values := range stored {
+		header[name] = slices.Clone(values)
+	}
+}
+
+// cacheResponseWriter records the status and body written through it.
+// Like the Context response writer, it keeps the first final status.
+type cacheResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteStatus bool
+	body        bytes.Buffer
+}
+
+func (w *cacheResponseWriter) WriteHeader(code int) {
+	interim := code >= 100 && code < 200 && code != http.StatusSwitchingProtocols
+	if !interim && !w.wroteStatus {
+		w.status = code
+		w.wroteStatus = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheResponseWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// MemoryCacheStore is an in-memory CacheStore that evicts the least
+// recently used entry once it holds maxEntries responses.
+type MemoryCacheStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key       string
+	resp      *CachedResponse
+	expiresAt time.Time
+}
+
+// NewMemoryCacheStore creates an in-memory LRU store bounded to maxEntries.
+func NewMemoryCacheStore(maxEntries int) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// Get implements CacheStore.
+func (s *MemoryCacheStore) Get(key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		s.ll.Remove(elem)
+		delete(s.items, key)
+		return nil, false
+	}
+	s.ll.MoveToFront(elem)
+	return entry.resp, true
+}
+
+// Set implements CacheStore.
+func (s *MemoryCacheStore) Set(key string, resp *CachedResponse, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if elem, ok := s.items[key]; ok {
+		entry := elem.Value.(*memoryCacheEntry)
+		entry.resp = resp
+		entry.expiresAt = expiresAt
+		s.ll.MoveToFront(elem)
+		return
+	}
+
+	s.items[key] = s.ll.PushFront(&memoryCacheEntry{key: key, resp: resp, expiresAt: expiresAt})
+	if s.maxEntries > 0 && s.ll.Len() > s.maxEntries {
+		oldest := s.ll.Back()
+		s.ll.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of stored entries, including expired ones not yet evicted.
+func (s *MemoryCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ll.Len()
+}
+
+// CoalesceConfig defines the configuration for the Coalesce middleware.
+type CoalesceConfig struct {
+	// VaryHeaders lists request headers whose values are part of the key,
+	// so that requests differing in them are not coalesced, e.g.
+	// "Authorization" for per-user responses.
+	VaryHeaders []string
+
+	// ShareCredentialed coalesces requests carrying Authorization or
+	// Cookie headers. By default they are passed through, since their
+	// responses are usually per user; listing the header in VaryHeaders
+	// also opts it in, keyed by its value.
+	ShareCredentialed bool
+}
+
+// Coalesce returns a middleware that collapses concurrent identical GET
+// requests, keyed like Cache, into a single handler call. While the first
+// request is in flight, later ones wait and are served a copy of its
+// status, headers and body. Like Cache, only headers set by the wrapped
+// handlers are shared, and responses setting cookies never are. If the
+// first request fails or its response is not cacheable, waiting requests
+// run the handler themselves. Requests with credentials are not coalesced
+// unless CoalesceConfig opts in.
+//
+// Placed in front of Cache it protects cold entries from a thundering
+// herd of misses:
+//
+//	app.Use(ginji.Coalesce(ginji.CoalesceConfig{}), ginji.Cache(cacheConfig))
+func Coalesce(config CoalesceConfig) Middleware {
+	var (
+		mu      sync.Mutex
+		flights = make(map[string]*flight)
+	)
+
+	return func(c *Context) error {
+		if c.Req.Method != http.MethodGet || !coalescible(c.Req, config) {
+			return c.Next()
+		}
+
+		key := cacheKey(c.Req, config.VaryHeaders)
+		mu.Lock()
+		if f, ok := flights[key]; ok {
+			mu.Unlock()
+			select {
+			case <-f.done:
+			case <-c.Req.Context().Done():
+				c.Abort()
+				return nil
+			}
+			if f.resp == nil {
+				return c.Next()
+			}
+			replayHeader(c.Res.Header(), f.resp.Header)
+			c.Res.WriteHeader(f.resp.Status)
+			c.Abort()
+			_, err := c.Res.Write(f.resp.Body)
+			return err
+		}
+		f := &flight{done: make(chan struct{})}
+		flights[key] = f
+		mu.Unlock()
+
+		// Release waiting requests even if the handler panics
+		defer func() {
+			mu.Lock()
+			delete(flights, key)
+			mu.Unlock()
+			close(f.done)
+		}()
+
+		before := c.Res.Header().Clone()
+		originalRes := c.Res
+		cw := &cacheResponseWriter{ResponseWriter: originalRes, status: http.StatusOK}
+		c.Res = cw
+		err := c.Next()
+		c.Res = originalRes
+
+		if err == nil && c.error == nil && isCacheable(cw.Header()) {
+			f.resp = &CachedResponse{
+				Status:   cw.status,
+				Header:   headerChanges(before, cw.Header()),
+				Body:     cw.body.Bytes(),
+				StoredAt: time.Now(),
+			}
+		}
+		return err
+	}
+}
+
+// coalescible reports whether req may share a response with other
+// requests: it carries no credentials, or config opts them in.
+func coalescible(req *http.Request, config CoalesceConfig) bool {
+	if config.ShareCredentialed {
+		return true
+	}
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if req.Header.Get(name) == "" {
+			continue
+		}
+		if !slices.ContainsFunc(config.VaryHeaders, func(h string) bool {
+			return http.CanonicalHeaderKey(h) == name
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// flight is a request being handled on behalf of coalesced requests.
+// resp is set before done is closed, and left nil if the response must
+// not be shared.
+type flight struct {
+	done chan struct{}
+	resp *CachedResponse
+}
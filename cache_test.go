@@ -0,0 +1,351 @@
+package ginji
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheMiddleware(t *testing.T) {
+	app := New()
+	app.Use(Cache(ResponseCacheConfig{TTL: time.Minute, VaryHeaders: []string{"Accept-Language"}}))
+
+	calls := 0
+	app.Get("/items", func(c *Context) error {
+		calls++
+		c.SetHeader("X-Lang", c.Header("Accept-Language"))
+		return c.Text(http.StatusOK, "items "+strconv.Itoa(calls))
+	})
+	app.Get("/private", func(c *Context) error {
+		calls++
+		c.SetHeader("Cache-Control", "no-store")
+		return c.Text(http.StatusOK, "private")
+	})
+
+	get := func(path, lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/items", "en")
+	if w.Body.String() != "items 1" || w.Header().Get("Age") != "" {
+		t.Fatalf("Expected uncached first response, got %q age %q", w.Body.String(), w.Header().Get("Age"))
+	}
+
+	w = get("/items", "en")
+	if w.Body.String() != "items 1" {
+		t.Errorf("Expected cached body, got %q", w.Body.String())
+	}
+	if w.Header().Get("Age") != "0" || w.Header().Get("X-Lang") != "en" {
+		t.Errorf("Expected cached headers with Age, got %v", w.Header())
+	}
+	if calls != 1 {
+		t.Errorf("Expected handler to be skipped on hit, got %d calls", calls)
+	}
+
+	// Different Vary header value and different query are separate entries
+	if w = get("/items", "fr"); w.Body.String() != "items 2" {
+		t.Errorf("Expected miss for different Accept-Language, got %q", w.Body.String())
+	}
+	if w = get("/items?page=2", "en"); w.Body.String() != "items 3" {
+		t.Errorf("Expected miss for different query, got %q", w.Body.String())
+	}
+
+	// no-store responses are never cached
+	get("/private", "en")
+	get("/private", "en")
+	if calls != 5 {
+		t.Errorf("Expected no-store response to bypass the cache, got %d calls", calls)
+	}
+}
+
+func TestCacheStoresOnlyHandlerHeaders(t *testing.T) {
+	app := New()
+	app.Use(RequestID(), Cache(ResponseCacheConfig{}))
+
+	calls := 0
+	app.Get("/public", func(c *Context) error {
+		calls++
+		c.SetHeader("X-Handler", "yes")
+		return c.Text(http.StatusOK, "public")
+	})
+	app.Get("/session", func(c *Context) error {
+		calls++
+		c.SetCookie(&http.Cookie{Name: "session", Value: "user-" + strconv.Itoa(calls)})
+		return c.Text(http.StatusOK, "session")
+	})
+
+	first := PerformRequest(app, "GET", "/public", nil)
+	second := PerformRequest(app, "GET", "/public", nil)
+	if calls != 1 || second.Header().Get("Age") == "" {
+		t.Fatalf("Expected second request to hit the cache, got %d calls", calls)
+	}
+	if second.Header().Get("X-Handler") != "yes" {
+		t.Errorf("Expected handler header to be replayed, got %v", second.Header())
+	}
+	if id := second.Header().Get("X-Request-ID"); id == "" || id == first.Header().Get("X-Request-ID") {
+		t.Errorf("Expected a fresh X-Request-ID on the hit, got %q", id)
+	}
+
+	calls = 0
+	PerformRequest(app, "GET", "/session", nil)
+	w := PerformRequest(app, "GET", "/session", nil)
+	if calls != 2 {
+		t.Errorf("Expected responses with Set-Cookie to bypass the cache, got %d calls", calls)
+	}
+	if cookie := w.Header().Get("Set-Cookie"); !strings.Contains(cookie, "user-2") {
+		t.Errorf("Expected the second user's own cookie, got %q", cookie)
+	}
+}
+
+func TestCacheKeepsFirstStatus(t *testing.T) {
+	app := New()
+	app.Use(Cache(ResponseCacheConfig{}))
+
+	calls := 0
+	app.Get("/missing", func(c *Context) error {
+		calls++
+		c.Status(http.StatusNotFound)
+		return c.JSON(http.StatusOK, H{"error": "not found"})
+	})
+
+	for i := 0; i < 2; i++ {
+		if w := PerformRequest(app, "GET", "/missing", nil); w.Code != http.StatusNotFound {
+			t.Errorf("Request %d: expected 404, got %d", i, w.Code)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Expected 404 responses not to be cached, got %d calls", calls)
+	}
+}
+
+func TestCacheRespectsResponseVary(t *testing.T) {
+	app := New()
+	app.Use(Cache(ResponseCacheConfig{}), Compress())
+
+	calls := 0
+	app.Get("/data", func(c *Context) error {
+		calls++
+		return c.Text(http.StatusOK, "data")
+	})
+
+	req := httptest.NewRequest("GET", "/data", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	app.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := PerformRequest(app, "GET", "/data", nil)
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "data" {
+		t.Errorf("Expected a plain body for a client without gzip, got %q %q", w.Header().Get("Content-Encoding"), w.Body.String())
+	}
+	if calls != 2 {
+		t.Errorf("Expected response varying on an unkeyed header not to be cached, got %d calls", calls)
+	}
+
+	// Keying on the header makes the variants cacheable
+	app = New()
+	app.Use(Cache(ResponseCacheConfig{VaryHeaders: []string{"Accept-Encoding"}}), Compress())
+	calls = 0
+	app.Get("/data", func(c *Context) error {
+		calls++
+		return c.Text(http.StatusOK, "data")
+	})
+	for i := 0; i < 2; i++ {
+		w = httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+	}
+	if calls != 1 || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("Expected keyed gzip variant to be cached, got %d calls, encoding %q", calls, w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := NewMemoryCacheStore(2)
+	resp := func(body string) *CachedResponse {
+		return &CachedResponse{Status: http.StatusOK, Body: []byte(body), StoredAt: time.Now()}
+	}
+
+	store.Set("a", resp("a"), time.Minute)
+	store.Set("b", resp("b"), time.Minute)
+	store.Get("a") // a is now most recently used
+	store.Set("c", resp("c"), time.Minute)
+
+	if _, ok := store.Get("b"); ok {
+		t.Error("Expected least recently used entry to be evicted")
+	}
+	if _, ok := store.Get("a"); !ok {
+		t.Error("Expected recently used entry to be kept")
+	}
+	if store.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", store.Len())
+	}
+
+	store.Set("expired", resp("x"), -time.Second)
+	if _, ok := store.Get("expired"); ok {
+		t.Error("Expected expired entry to be missed")
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	app := New()
+	app.Use(Coalesce(CoalesceConfig{}))
+
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	app.Get("/slow", func(c *Context) error {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		c.SetHeader("X-Source", "handler")
+		return c.Text(http.StatusOK, "slow "+strconv.Itoa(int(calls.Load())))
+	})
+	app.Get("/failing", func(c *Context) error {
+		calls.Add(1)
+		c.AbortWithError(http.StatusInternalServerError, NewHTTPError(http.StatusInternalServerError))
+		return nil
+	})
+
+	const waiters = 4
+	results := make([]*httptest.ResponseRecorder, waiters+1)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = PerformRequest(app, "GET", "/slow", nil)
+		}(i)
+		if i == 0 {
+			<-started
+		}
+	}
+	// Give the waiting requests time to join the in-flight one
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected handler to run once, got %d calls", calls.Load())
+	}
+	for i, w := range results {
+		if w.Code != http.StatusOK || w.Body.String() != "slow 1" || w.Header().Get("X-Source") != "handler" {
+			t.Errorf("Request %d: expected shared response, got %d %q %v", i, w.Code, w.Body.String(), w.Header())
+		}
+	}
+
+	// Once the flight has landed, requests run the handler again
+	if w := PerformRequest(app, "GET", "/slow", nil); w.Body.String() != "slow 2" {
+		t.Errorf("Expected a fresh call after the flight, got %q", w.Body.String())
+	}
+
+	// Failed responses are not shared
+	calls.Store(0)
+	PerformRequest(app, "GET", "/failing", nil)
+	PerformRequest(app, "GET", "/failing", nil)
+	if calls.Load() != 2 {
+		t.Errorf("Expected failing handler to run for each request, got %d calls", calls.Load())
+	}
+}
+
+func TestCoalesceKeepsUsersApart(t *testing.T) {
+	app := New()
+	app.Use(Coalesce(CoalesceConfig{}))
+
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	app.Get("/me", func(c *Context) error {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		user := c.Header("Cookie")
+		c.SetCookie(&http.Cookie{Name: "seen", Value: user})
+		return c.Text(http.StatusOK, user)
+	})
+
+	request := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/me", nil)
+		req.Header.Set("Cookie", user)
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w
+	}
+
+	var alice, bob *httptest.ResponseRecorder
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		alice = request("alice")
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		bob = request("bob")
+	}()
+	// Bob must not wait for Alice's in-flight request
+	time.Sleep(100 * time.Millisecond)
+	if calls.Load() != 2 {
+		t.Errorf("Expected each user's request to run the handler, got %d calls", calls.Load())
+	}
+	close(release)
+	wg.Wait()
+
+	if alice.Body.String() != "alice" || !strings.Contains(alice.Header().Get("Set-Cookie"), "alice") {
+		t.Errorf("Expected Alice's own response, got %q %v", alice.Body.String(), alice.Header())
+	}
+	if bob.Body.String() != "bob" || !strings.Contains(bob.Header().Get("Set-Cookie"), "bob") {
+		t.Errorf("Expected Bob's own response, got %q %v", bob.Body.String(), bob.Header())
+	}
+}
+
+func TestCoalesceSharesFirstStatus(t *testing.T) {
+	app := New()
+	app.Use(Coalesce(CoalesceConfig{}))
+
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	app.Get("/job", func(c *Context) error {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		c.Status(http.StatusAccepted)
+		return c.Text(http.StatusOK, "queued")
+	})
+
+	var leader, waiter *httptest.ResponseRecorder
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		leader = PerformRequest(app, "GET", "/job", nil)
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		waiter = PerformRequest(app, "GET", "/job", nil)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected handler to run once, got %d calls", calls.Load())
+	}
+	for name, w := range map[string]*httptest.ResponseRecorder{"leader": leader, "waiter": waiter} {
+		if w.Code != http.StatusAccepted || w.Body.String() != "queued" {
+			t.Errorf("%s: expected 202 queued, got %d %q", name, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ginji
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNoClaims is returned when a typed request has fields tagged "claim"
+// but no claims were stored with Context.SetClaims, usually because the
+// authentication middleware does not run for the route.
+var ErrNoClaims = errors.New("no claims in context: is the authentication middleware installed for this route?")
+
+// claimsKey holds the claims stored with SetClaims.
+var claimsKey = &contextKey{"claims"}
+
+// SetClaims stores the verified claims of the request's token, e.g. the
+// payload of a JWT, so that typed handlers can bind them into fields
+// tagged with the claim name:
+//
+//	type Request struct {
+//		UserID string   `claim:"sub" json:"-"`
+//		Roles  []string `claim:"roles" json:"-"`
+//	}
+//
+// It is meant to be called by authentication middleware once the token is
+// verified; claims are trusted as given.
+func (c *Context) SetClaims(claims map[string]any) {
+	c.SetKeyed(claimsKey, claims)
+}
+
+// Claims returns the claims stored with SetClaims.
+func (c *Context) Claims() (map[string]any, bool) {
+	value, _ := c.GetKeyed(claimsKey)
+	claims, ok := value.(map[string]any)
+	return claims, ok
+}
+
+// bindClaims sets the fields of the struct v points to that are tagged
+// "claim" from the claims of c. Fields whose claim is absent are zeroed,
+// so they can never carry values taken from the request.
+func bindClaims(c *Context, v any) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	val = val.Elem()
+
+	claims, haveClaims := c.Claims()
+	var errs BindFieldErrors
+	for _, field := range reflect.VisibleFields(val.Type()) {
+		name := field.Tag.Get("claim")
+		if name == "" || !field.IsExported() {
+			continue
+		}
+		if !haveClaims {
+			return ErrNoClaims
+		}
+		fieldVal, err := val.FieldByIndexErr(field.Index)
+		if err != nil {
+			continue
+		}
+
+		fieldVal.SetZero()
+		claim, ok := claims[name]
+		if !ok || claim == nil {
+			continue
+		}
+		// Claims decoded from JSON hold float64 and []any, so convert
+		// through JSON into the field type
+		data, err := json.Marshal(claim)
+		if err == nil {
+			err = json.Unmarshal(data, fieldVal.Addr().Interface())
+		}
+		if err != nil {
+			fieldVal.SetZero()
+			errs = append(errs, BindFieldError{
+				Field:  name,
+				Source: "claim",
+				Value:  fmt.Sprint(claim),
+				Err:    err,
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package ginji
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBindClaims(t *testing.T) {
+	type Request struct {
+		UserID string   `claim:"sub" json:"user_id"`
+		Roles  []string `claim:"roles" json:"-"`
+		Level  int      `claim:"level" json:"-"`
+		Tenant string   `claim:"tenant" json:"tenant"`
+		Note   string   `json:"note"`
+	}
+	type Response struct {
+		Request
+		Roles []string `json:"roles"`
+		Level int      `json:"level"`
+	}
+	handler := TypedHandlerFunc(func(c *Context, req Request) (Response, error) {
+		return Response{Request: req, Roles: req.Roles, Level: req.Level}, nil
+	})
+
+	// Claims as decoded from a JWT payload
+	var claims map[string]any
+	if err := json.Unmarshal([]byte(`{"sub":"alice","roles":["admin","dev"],"level":3}`), &claims); err != nil {
+		t.Fatal(err)
+	}
+	app := New()
+	auth := func(c *Context) error {
+		c.SetClaims(claims)
+		return c.Next()
+	}
+	app.Post("/items", handler).Middlewares(auth)
+	app.Post("/unauthenticated", handler)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w
+	}
+
+	// Request data cannot override claims, present or absent
+	w := post("/items", `{"user_id":"mallory","tenant":"other","note":"hi"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var res Response
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.UserID != "alice" || res.Tenant != "" || res.Note != "hi" {
+		t.Errorf("Expected claims to win over the body, got %+v", res)
+	}
+	if strings.Join(res.Roles, ",") != "admin,dev" || res.Level != 3 {
+		t.Errorf("Expected roles and level from claims, got %v %d", res.Roles, res.Level)
+	}
+
+	// Without the authentication middleware the route is misconfigured
+	if w := post("/unauthenticated", `{}`); w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 without claims, got %d", w.Code)
+	}
+
+	claims["level"] = "high"
+	if w := post("/items", `{}`); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "level") {
+		t.Errorf("Expected 400 naming the level claim, got %d %s", w.Code, w.Body.String())
+	}
+}
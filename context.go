@@ -1,34 +1,126 @@
 package ginji
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/netip"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// responseWriter wraps http.ResponseWriter to capture status code.
+// responseWriter wraps http.ResponseWriter to capture status code. The
+// final status is held back until the body is first written or flushed, or
+// the request ends, so headers set after the status still reach the client.
 type responseWriter struct {
 	http.ResponseWriter
-	status int
-	size   int
+	status      int
+	size        int
+	pending     bool // status set but not sent yet
+	wroteHeader bool
+	timings     []string // pending Server-Timing entries
 }
 
 func (w *responseWriter) WriteHeader(code int) {
+	// Informational responses such as 100 Continue are interim; the final
+	// status is still to come
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	// The status is already set or sent; like net/http, the first one
+	// wins, so a later handler cannot turn a denial into a success
+	if w.wroteHeader || w.pending {
+		return
+	}
 	w.status = code
-	w.ResponseWriter.WriteHeader(code)
+	w.pending = true
+	if code == http.StatusSwitchingProtocols {
+		w.writeHeaderNow()
+	}
 }
 
-func (w *responseWriter) Write(b []byte) (int, error) {
+// writeHeaderNow sends the status line and headers unless already sent.
+func (w *responseWriter) writeHeaderNow() {
+	if w.wroteHeader {
+		return
+	}
 	if w.status == 0 {
 		w.status = http.StatusOK
 	}
+	w.flushServerTiming()
+	w.wroteHeader = true
+	w.pending = false
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// finish sends a status that was set but never followed by a body.
+func (w *responseWriter) finish() {
+	if w.pending {
+		w.writeHeaderNow()
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.writeHeaderNow()
 	n, err := w.ResponseWriter.Write(b)
 	w.size += n
 	return n, err
 }
 
+// flushServerTiming sets the Server-Timing header from the recorded entries
+// before the headers are sent.
+func (w *responseWriter) flushServerTiming() {
+	if w.wroteHeader || len(w.timings) == 0 {
+		return
+	}
+	w.Header().Set("Server-Timing", strings.Join(w.timings, ", "))
+}
+
+// Flush implements http.Flusher.
+func (w *responseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// FlushError sends buffered data to the client and reports write errors,
+// e.g. when the client has disconnected. http.ResponseController uses it.
+func (w *responseWriter) FlushError() error {
+	w.writeHeaderNow()
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Written reports whether the response is decided: a status was set, even
+// if not sent yet, or the status line or body bytes were sent.
+func (w *responseWriter) Written() bool {
+	return w.wroteHeader || w.pending || w.size > 0
+}
+
+// discardResponseWriter drops everything written to it. It backs copies
+// of a Context, which must not write to the original response.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w discardResponseWriter) Header() http.Header         { return w.header }
+func (w discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w discardResponseWriter) WriteHeader(int)             {}
+
 // Req wraps http.Request to provide cleaner API access to request data.
 // Inspired by Hono.js request namespace pattern.
 type Req struct {
@@ -62,6 +154,8 @@ type Context struct {
 	Params   map[string]string
 	writer   *responseWriter
 	Keys     map[string]any
+	keyed    map[any]any   // values stored with SetKeyed under non-string keys
+	cleanups []func()      // run when the request ends, before c is reused
 	error    error         // error to be handled by error middleware
 	written  bool          // whether response has been written
 	aborted  bool          // whether request processing should stop
@@ -69,6 +163,8 @@ type Context struct {
 	handlers []Handler     // middleware chain
 	index    int8          // current handler index
 	engine   *Engine       // reference to engine for error handler access
+	group    *RouterGroup  // group of the matched route, if any
+	pattern  string        // pattern of the matched route, if any
 }
 
 // NewContext creates a new Context instance.
@@ -102,17 +198,24 @@ func (c *Context) Reset(w http.ResponseWriter, r *http.Request, engine *Engine)
 	c.writer.ResponseWriter = w
 	c.writer.status = 200
 	c.writer.size = 0
+	c.writer.pending = false
+	c.writer.wroteHeader = false
+	c.writer.timings = c.writer.timings[:0]
 	c.Req = r
 	c.Res = c.writer
 	c.Params = make(map[string]string)
 	c.Request = &Req{Request: r, params: c.Params}
 	c.Keys = make(map[string]any)
+	c.keyed = nil
+	c.cleanups = c.cleanups[:0]
 	c.written = false
 	c.aborted = false
 	c.error = nil
 	c.index = -1
 	c.handlers = c.handlers[:0]
 	c.engine = engine
+	c.group = nil
+	c.pattern = ""
 
 	// Dispose old service scope before creating new one to prevent memory leaks
 	if c.services != nil {
@@ -126,11 +229,13 @@ func (c *Context) Reset(w http.ResponseWriter, r *http.Request, engine *Engine)
 	}
 }
 
-// DeepCopy creates a deep copy of the context for safe concurrent use.
-// This is useful when passing context to goroutines (e.g., in timeout middleware).
-// Maps (Keys, Params) are copied to prevent race conditions.
+// DeepCopy returns a copy of the context that shares the live request,
+// response and handler chain with c but has its own Params and Keys, so
+// the copy and c can read and set values concurrently. It is meant for
+// goroutines that finish before the handler returns, such as a timeout
+// middleware racing the handler, and may write the response. For
+// goroutines that outlive the handler, use Copy.
 func (c *Context) DeepCopy() *Context {
-	// Create new context with copied values
 	cp := &Context{
 		Req:     c.Req,
 		Res:     c.Res,
@@ -140,34 +245,166 @@ func (c *Context) DeepCopy() *Context {
 		error:   c.error,
 		index:   c.index,
 	}
+	c.copyValues(cp)
+	cp.Request = &Req{Request: c.Req, params: cp.Params}
+	cp.handlers = slices.Clone(c.handlers)
 
-	// Deep copy maps to prevent race conditions
-	cp.Params = make(map[string]string, len(c.Params))
-	for k, v := range c.Params {
-		cp.Params[k] = v
+	// Service scope is shared (read-only operations in handlers are safe)
+	// If handlers need to modify services, they should do so through the container
+	cp.services = c.services
+
+	return cp
+}
+
+// Copy returns a detached copy of the context for goroutines that outlive
+// the handler; c itself is reused for another request once the handler
+// returns, so it must not be retained. Call Copy within the handler:
+//
+//	cp := c.Copy()
+//	go func() {
+//		audit(cp, cp.Param("id"), cp.MustGet("user"))
+//	}()
+//
+// The copy carries the request with a context that is not cancelled when
+// the request ends, and its own copies of Params and Keys. It has no
+// middleware chain and no response: writing the response through a copy
+// is unsafe, so anything written to it is discarded. To share the live
+// response with a goroutine that ends before the handler, use DeepCopy.
+func (c *Context) Copy() *Context {
+	req := c.Req.WithContext(context.WithoutCancel(c.requestContext()))
+	writer := &responseWriter{
+		ResponseWriter: discardResponseWriter{header: make(http.Header)},
+		status:         c.StatusCode(),
+		wroteHeader:    true,
+	}
+
+	cp := &Context{
+		Req:     req,
+		Res:     writer,
+		writer:  writer,
+		written: true,
+		aborted: true,
+		index:   -1,
 	}
+	c.copyValues(cp)
+	cp.Request = &Req{Request: req, params: cp.Params}
+	return cp
+}
 
+// copyValues gives cp the route of c and its own copies of the request
+// values, shared by Copy and DeepCopy.
+func (c *Context) copyValues(cp *Context) {
+	cp.engine = c.engine
+	cp.group = c.group
+	cp.pattern = c.pattern
+	cp.Params = make(map[string]string, len(c.Params))
+	maps.Copy(cp.Params, c.Params)
 	cp.Keys = make(map[string]any, len(c.Keys))
-	for k, v := range c.Keys {
-		cp.Keys[k] = v
+	maps.Copy(cp.Keys, c.Keys)
+	cp.keyed = maps.Clone(c.keyed)
+}
+
+// Context implements context.Context by delegating to the request context,
+// so it can be passed directly to database and HTTP clients.
+var _ context.Context = (*Context)(nil)
+
+// requestContext returns the context of the underlying request.
+func (c *Context) requestContext() context.Context {
+	if c.Req == nil {
+		return context.Background()
 	}
+	return c.Req.Context()
+}
 
-	// Copy handlers slice
-	cp.handlers = make([]Handler, len(c.handlers))
-	copy(cp.handlers, c.handlers)
+// Deadline returns the deadline of the request context, if any.
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	return c.requestContext().Deadline()
+}
 
-	// Service scope is shared (read-only operations in handlers are safe)
-	// If handlers need to modify services, they should do so through the container
-	cp.services = c.services
+// Done returns a channel that is closed when the request is canceled,
+// for example because the client disconnected.
+func (c *Context) Done() <-chan struct{} {
+	return c.requestContext().Done()
+}
 
-	return cp
+// Err returns the request context error once Done is closed.
+func (c *Context) Err() error {
+	return c.requestContext().Err()
+}
+
+// Value returns the value stored with Set or SetKeyed under key, falling
+// back to the request context.
+func (c *Context) Value(key any) any {
+	if val, exists := c.GetKeyed(key); exists {
+		return val
+	}
+	return c.requestContext().Value(key)
 }
 
 // Set stores a new key/value pair exclusively for this context.
+//
+// String keys are shared by all middleware and handlers, so two of them
+// may pick the same name. Middleware meant for reuse should store values
+// with SetKeyed under an unexported key type and offer an accessor, as
+// the built-in middleware do:
+//
+//	type tenantKey struct{}
+//
+//	func Tenant(c *ginji.Context) string {
+//		tenant, _ := c.GetKeyed(tenantKey{})
+//		s, _ := tenant.(string)
+//		return s
+//	}
 func (c *Context) Set(key string, value any) {
 	c.Keys[key] = value
 }
 
+// SetKeyed stores a value under a key of any comparable type, like
+// context.WithValue. Keys of distinct types never collide, so values
+// stored under an unexported key type cannot be overwritten by other
+// packages. String keys are stored in Keys, as with Set.
+func (c *Context) SetKeyed(key, value any) {
+	if k, ok := key.(string); ok {
+		c.Keys[k] = value
+		return
+	}
+	if c.keyed == nil {
+		c.keyed = make(map[any]any)
+	}
+	c.keyed[key] = value
+}
+
+// GetKeyed returns the value stored with SetKeyed, or with Set for string
+// keys.
+func (c *Context) GetKeyed(key any) (any, bool) {
+	if k, ok := key.(string); ok {
+		return c.Get(k)
+	}
+	value, exists := c.keyed[key]
+	return value, exists
+}
+
+// contextKey is the key type for values the built-in middleware store on
+// the context, so they cannot collide with user keys.
+type contextKey struct {
+	name string
+}
+
+func (k *contextKey) String() string {
+	return "ginji context key " + k.name
+}
+
+// requestIDKey holds the ID assigned by the RequestID middleware.
+var requestIDKey = &contextKey{"request_id"}
+
+// RequestID returns the ID assigned to the request by the RequestID
+// middleware, or "" if it is not in use.
+func (c *Context) RequestID() string {
+	id, _ := c.GetKeyed(requestIDKey)
+	s, _ := id.(string)
+	return s
+}
+
 // Get returns the value for the given key.
 func (c *Context) Get(key string) (any, bool) {
 	value, exists := c.Keys[key]
@@ -179,64 +416,325 @@ func (c *Context) Param(key string) string {
 	return c.Params[key]
 }
 
-// Status sets the HTTP status code.
+// Status sets the HTTP status code. It is sent with the headers when the
+// body is first written or flushed, or when the handler returns, so headers
+// may still be set after Status. The first status set wins: later calls,
+// including the status passed to JSON or Text, are ignored, so a handler
+// cannot turn a status set by middleware into another one.
+// The reason phrase on the status line is always the standard one from
+// http.StatusText: net/http writes the status line itself and offers no way
+// to customize it, so a custom phrase would require hijacking the connection
+// and is not supported.
 func (c *Context) Status(code int) *Context {
 	c.Res.WriteHeader(code)
 	return c
 }
 
+// ExpectsContinue reports whether the client sent "Expect: 100-continue"
+// and is waiting for an interim response before uploading the body.
+func (c *Context) ExpectsContinue() bool {
+	return strings.EqualFold(c.Req.Header.Get("Expect"), "100-continue")
+}
+
+// Continue sends an interim 100 Continue response if the client expects
+// one and no response has been started. It is rarely needed: net/http
+// sends 100 Continue automatically when the body is first read, so
+// middleware that rejects a request before reading its body (for example
+// failed auth or an oversized Content-Length) answers with the final
+// status and the client never uploads the body.
+func (c *Context) Continue() {
+	if c.ExpectsContinue() && !c.Written() {
+		c.Res.WriteHeader(http.StatusContinue)
+	}
+}
+
+// onRequestEnd registers fn to run when the request ends, before the
+// final response is sent and c is returned to the pool. It stops work
+// bound to the request, such as a stream's keep-alive goroutine.
+func (c *Context) onRequestEnd(fn func()) {
+	c.cleanups = append(c.cleanups, fn)
+}
+
+// runCleanups runs the functions registered with onRequestEnd, last first.
+func (c *Context) runCleanups() {
+	for i := len(c.cleanups) - 1; i >= 0; i-- {
+		c.cleanups[i]()
+	}
+	c.cleanups = c.cleanups[:0]
+}
+
+// Written reports whether the response has already started, either because
+// a body was sent or because a status was set with Status or WriteHeader.
+func (c *Context) Written() bool {
+	return c.written || (c.writer != nil && c.writer.Written())
+}
+
+// logger returns the engine logger, falling back to the default slog logger.
+func (c *Context) logger() *slog.Logger {
+	if c.engine != nil && c.engine.Logger != nil {
+		return c.engine.Logger
+	}
+	return slog.Default()
+}
+
+// ClientIP returns the IP address of the client. For requests from a
+// proxy trusted with Engine.SetTrustedProxies, it is the last address in
+// X-Forwarded-For that is not itself a trusted proxy, or else X-Real-IP;
+// otherwise it is the address of the connection.
+func (c *Context) ClientIP() string {
+	host, _, err := net.SplitHostPort(c.Req.RemoteAddr)
+	if err != nil {
+		host = c.Req.RemoteAddr
+	}
+	remote, err := netip.ParseAddr(host)
+	if err != nil || c.engine == nil || !c.engine.isTrustedProxy(remote.Unmap()) {
+		return host
+	}
+
+	// Walk the chain from the nearest hop back towards the client
+	forwarded := strings.Split(strings.Join(c.Req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		if !c.engine.isTrustedProxy(ip.Unmap()) {
+			return ip.Unmap().String()
+		}
+	}
+	if ip, err := netip.ParseAddr(strings.TrimSpace(c.Req.Header.Get("X-Real-IP"))); err == nil {
+		return ip.Unmap().String()
+	}
+	return host
+}
+
+// RoutePattern returns the pattern of the matched route, e.g.
+// "/users/:id", or "" if no route matched. Unlike the request path it has
+// few distinct values, which suits logs and metrics labels.
+func (c *Context) RoutePattern() string {
+	return c.pattern
+}
+
 // StatusCode returns the HTTP status code.
 func (c *Context) StatusCode() int {
 	return c.writer.status
 }
 
+// ResponseSize returns the number of response body bytes written so far.
+func (c *Context) ResponseSize() int {
+	return c.writer.size
+}
+
 // SetHeader sets a response header.
 func (c *Context) SetHeader(key, value string) *Context {
 	c.Res.Header().Set(key, value)
 	return c
 }
 
+// AddVary adds headers to the Vary response header, keeping values set
+// by other middleware and skipping duplicates.
+func (c *Context) AddVary(headers ...string) *Context {
+	mergeHeaderValues(c.Res.Header(), "Vary", headers...)
+	return c
+}
+
+// mergeHeaderValues merges values into the comma-separated list header
+// key, comparing case-insensitively. A "*" already present absorbs any
+// further values.
+func mergeHeaderValues(h http.Header, key string, values ...string) {
+	var merged []string
+	for _, line := range h.Values(key) {
+		for _, v := range strings.Split(line, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				merged = append(merged, v)
+			}
+		}
+	}
+	for _, v := range values {
+		if slices.Contains(merged, "*") {
+			break
+		}
+		if v = strings.TrimSpace(v); v != "" && !slices.ContainsFunc(merged, func(m string) bool {
+			return strings.EqualFold(m, v)
+		}) {
+			merged = append(merged, v)
+		}
+	}
+	if len(merged) > 0 {
+		h.Set(key, strings.Join(merged, ", "))
+	}
+}
+
+// ServerTiming records a Server-Timing metric, e.g. the time spent in a
+// database query, so browsers can show it in their developer tools.
+// Entries are accumulated and sent as a single header when the response
+// headers are written; metrics recorded after that are dropped.
+//
+//	start := time.Now()
+//	rows, err := db.Query(...)
+//	c.ServerTiming("db", time.Since(start), "users query")
+func (c *Context) ServerTiming(name string, dur time.Duration, desc string) {
+	if c.writer == nil || c.writer.wroteHeader {
+		return
+	}
+	entry := name + ";dur=" + strconv.FormatFloat(float64(dur)/float64(time.Millisecond), 'f', -1, 64)
+	if desc != "" {
+		entry += ";desc=" + strconv.Quote(desc)
+	}
+	c.writer.timings = append(c.writer.timings, entry)
+}
+
 // Query returns the query parameter value.
 func (c *Context) Query(key string) string {
 	return c.Req.URL.Query().Get(key)
 }
 
+// QueryArray returns all values of a repeated query parameter,
+// e.g. ["a", "b"] for ?tag=a&tag=b.
+func (c *Context) QueryArray(key string) []string {
+	return c.Req.URL.Query()[key]
+}
+
+// QueryMap returns the bracketed query parameters grouped under key,
+// e.g. {"name": "x", "age": "30"} for ?filter[name]=x&filter[age]=30.
+// Malformed or nested brackets are ignored.
+func (c *Context) QueryMap(key string) map[string]string {
+	return bracketMap(c.Req.URL.Query(), key)
+}
+
 // Header returns the header value.
 func (c *Context) Header(key string) string {
 	return c.Req.Header.Get(key)
 }
 
-// BindJSON binds the request body to a struct and validates it.
+// BindJSON binds the request body to a struct and validates it. For a
+// slice target such as *[]Item, every element is validated and errors are
+// reported with indexed fields like "[1].Name".
 func (c *Context) BindJSON(v any) error {
-	if err := json.NewDecoder(c.Req.Body).Decode(v); err != nil {
+	if err := c.ParseJSON(v); err != nil {
 		return err
 	}
-	return validateStruct(v)
+	return c.validate(v)
+}
+
+// ParseJSON decodes the request body into v without validating it. Together
+// with Validate it lets handlers tell malformed input from invalid input:
+//
+//	if err := c.ParseJSON(&req); err != nil {
+//		c.AbortWithError(http.StatusBadRequest, ginji.HumanizeBindError(err))
+//		return nil
+//	}
+//	if err := c.Validate(&req); err != nil {
+//		c.AbortWithError(http.StatusUnprocessableEntity, err)
+//		return nil
+//	}
+//
+// Unknown fields are rejected if the engine sets DisallowUnknownFields.
+func (c *Context) ParseJSON(v any) error {
+	return decodeJSONBody(c.Req.Body, v, c.disallowUnknownFields())
+}
+
+// BindJSONStrict is like BindJSON but always rejects fields in the body
+// that match no field of v, regardless of the engine setting.
+func (c *Context) BindJSONStrict(v any) error {
+	if err := decodeJSONBody(c.Req.Body, v, true); err != nil {
+		return err
+	}
+	return c.validate(v)
+}
+
+// disallowUnknownFields reports whether JSON bodies must not contain
+// unknown fields.
+func (c *Context) disallowUnknownFields() bool {
+	return c.engine != nil && c.engine.DisallowUnknownFields
+}
+
+// BindJSONFields binds only the allowed top-level JSON keys of the request
+// body to v and validates it. Keys not listed in allow are dropped before
+// decoding, so clients cannot set fields they are not meant to, e.g. when
+// a database model is reused as the request body:
+//
+//	var user User
+//	err := c.BindJSONFields(&user, "name", "email")
+//
+// Keys are matched case-insensitively, like encoding/json. With
+// Engine.DisallowUnknownFields, a body with keys that match no field of v
+// is rejected as with BindJSON, whether or not the keys are allowed.
+func (c *Context) BindJSONFields(v any, allow ...string) error {
+	body, err := io.ReadAll(c.Req.Body)
+	if err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := decodeJSONBody(bytes.NewReader(body), &raw, false); err != nil {
+		return err
+	}
+	if c.disallowUnknownFields() {
+		// Check the whole body against a scratch value of v's type, as
+		// filtering would hide unknown keys
+		if t := reflect.TypeOf(v); t != nil && t.Kind() == reflect.Ptr {
+			if err := decodeJSONBody(bytes.NewReader(body), reflect.New(t.Elem()).Interface(), true); err != nil {
+				return err
+			}
+		}
+	}
+
+	filtered := make(map[string]json.RawMessage, len(allow))
+	for key, value := range raw {
+		for _, name := range allow {
+			if strings.EqualFold(key, name) {
+				filtered[key] = value
+				break
+			}
+		}
+	}
+
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return c.validate(v)
 }
 
 // BindValidate is a convenience method that binds and validates in one call.
 // It automatically detects the content type and binds accordingly.
 func (c *Context) BindValidate(v any) error {
-	contentType := c.Header("Content-Type")
+	mt := mediaType(c.Header("Content-Type"))
 
-	// Handle JSON content type
-	if strings.Contains(contentType, "application/json") {
+	// Handle JSON content type, including +json vendor types
+	if isJSONMediaType(mt) {
 		return c.BindJSON(v)
 	}
 
 	// Handle form data
-	if strings.Contains(contentType, "application/x-www-form-urlencoded") ||
-		strings.Contains(contentType, "multipart/form-data") {
-		if err := bindForm(c.Req, v); err != nil {
+	if isFormMediaType(mt) {
+		if err := bindForm(c.Req, v, c.maxMultipartMemory()); err != nil {
 			return err
 		}
-		return validateStruct(v)
+		return c.validate(v)
 	}
 
 	// Default to JSON
 	return c.BindJSON(v)
 }
 
+// Validate checks v against its validation tags and the engine's custom
+// validators. Failures are returned as ValidationErrors.
+func (c *Context) Validate(v any) error {
+	return c.validate(v)
+}
+
+// validate validates v using the engine's validators, falling back to globals.
+func (c *Context) validate(v any) error {
+	if c.engine != nil {
+		return validateStructWith(v, c.engine.validators)
+	}
+	return validateStruct(v)
+}
+
 // Send writes a byte slice to the response.
 func (c *Context) Send(body []byte) error {
 	c.written = true
@@ -246,23 +744,55 @@ func (c *Context) Send(body []byte) error {
 
 // Text writes a string to the response with a status code.
 func (c *Context) Text(code int, text string) error {
-	c.Status(code)
 	c.SetHeader("Content-Type", "text/plain")
+	c.Status(code)
 	return c.Send([]byte(text))
 }
 
 // HTML writes an HTML string to the response with a status code.
 func (c *Context) HTML(code int, html string) error {
-	c.Status(code)
 	c.SetHeader("Content-Type", "text/html")
+	c.Status(code)
 	return c.Send([]byte(html))
 }
 
 // JSON writes a JSON object to the response with a status code.
+// Response transformers registered on the engine are applied to v first.
 func (c *Context) JSON(code int, v any) error {
-	c.Status(code)
 	c.SetHeader("Content-Type", "application/json")
-	return json.NewEncoder(c.Res).Encode(v)
+	c.Status(code)
+	if c.engine != nil {
+		v = c.engine.transformResponse(c, v)
+	}
+	data, err := jsonMarshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Send(append(data, '\n'))
+}
+
+// Blob writes binary data with the given content type and status code,
+// e.g. a generated image or PDF. Content-Length is set from len(data).
+func (c *Context) Blob(code int, contentType string, data []byte) error {
+	c.SetHeader("Content-Type", contentType)
+	c.SetHeader("Content-Length", strconv.Itoa(len(data)))
+	c.Status(code)
+	return c.Send(data)
+}
+
+// Reader streams r to the response with the given content type and status
+// code. When length is non-negative it is sent as Content-Length and at most
+// length bytes are copied.
+func (c *Context) Reader(code int, contentType string, r io.Reader, length int64) error {
+	c.SetHeader("Content-Type", contentType)
+	if length >= 0 {
+		c.SetHeader("Content-Length", strconv.FormatInt(length, 10))
+		r = io.LimitReader(r, length)
+	}
+	c.Status(code)
+	c.written = true
+	_, err := io.Copy(c.Res, r)
+	return err
 }
 
 // JSONOK writes a JSON object to the response with 200 OK status.
@@ -317,7 +847,7 @@ func (c *Context) BindQuery(v any) error {
 	if err := bindMap(c.Req.URL.Query(), v, "query"); err != nil {
 		return err
 	}
-	return validateStruct(v)
+	return c.validate(v)
 }
 
 // BindHeader binds headers to a struct and validates.
@@ -325,7 +855,7 @@ func (c *Context) BindHeader(v any) error {
 	if err := bindMap(c.Req.Header, v, "header"); err != nil {
 		return err
 	}
-	return validateStruct(v)
+	return c.validate(v)
 }
 
 // BindPath binds path parameters to a struct and validates.
@@ -333,41 +863,158 @@ func (c *Context) BindPath(v any) error {
 	if err := bindParams(c.Params, v); err != nil {
 		return err
 	}
-	return validateStruct(v)
+	return c.validate(v)
 }
 
-// BindAll binds from all sources (path, query, header, body) and validates.
+// BindSource identifies a request source that Bind and BindAll read from.
+type BindSource string
+
+// Bind sources, see Engine.SetBindPrecedence.
+const (
+	BindPath   BindSource = "path"
+	BindBody   BindSource = "body"
+	BindQuery  BindSource = "query"
+	BindHeader BindSource = "header"
+	BindCookie BindSource = "cookie"
+)
+
+// defaultBindPrecedence lists bind sources from highest to lowest precedence.
+var defaultBindPrecedence = []BindSource{BindPath, BindBody, BindQuery, BindHeader, BindCookie}
+
+// SetBindPrecedence sets which source wins, highest first, when Bind or
+// BindAll finds a field's value in several sources. Sources left out are
+// not read at all. For example, to let query parameters override the body:
+//
+//	app.SetBindPrecedence(ginji.BindPath, ginji.BindQuery, ginji.BindBody, ginji.BindHeader, ginji.BindCookie)
+func (engine *Engine) SetBindPrecedence(sources ...BindSource) {
+	engine.bindPrecedence = append([]BindSource(nil), sources...)
+}
+
+// BindAll binds path parameters, query parameters, headers, and the body
+// (JSON or form) to v, then validates the result. When the same field is
+// populated by several sources, the engine's bind precedence decides which
+// value wins (path > body > query > header by default).
 func (c *Context) BindAll(v any) error {
-	// Bind path parameters first
-	if err := bindParams(c.Params, v); err != nil {
+	sources := map[string]bool{"path": true, "query": true, "header": true, "body": true}
+	if err := c.bindFrom(v, sources); err != nil {
 		return err
 	}
+	return c.validate(v)
+}
 
-	// Bind query parameters
-	if err := bindMap(c.Req.URL.Query(), v, "query"); err != nil {
+// Bind inspects the struct tags of v and binds each field from the sources
+// it references (path, query, header, cookie, and json/form for the body),
+// then validates the result. Only referenced sources are read.
+//
+// When the same field is populated by several sources, the engine's bind
+// precedence decides which value wins; by default it is
+// path > body > query > header > cookie.
+func (c *Context) Bind(v any) error {
+	if err := c.bindFrom(v, bindSources(reflect.TypeOf(v))); err != nil {
 		return err
 	}
+	return c.validate(v)
+}
 
-	// Bind headers
-	if err := bindMap(c.Req.Header, v, "header"); err != nil {
-		return err
+// bindFrom binds v from the given sources, from lowest to highest
+// precedence so that higher precedence sources win.
+func (c *Context) bindFrom(v any, sources map[string]bool) error {
+	precedence := defaultBindPrecedence
+	if c.engine != nil && c.engine.bindPrecedence != nil {
+		precedence = c.engine.bindPrecedence
 	}
 
-	// Bind body if present
-	contentType := c.Header("Content-Type")
-	if strings.Contains(contentType, "application/json") {
-		if err := json.NewDecoder(c.Req.Body).Decode(v); err != nil {
-			return err
+	for i := len(precedence) - 1; i >= 0; i-- {
+		if !sources[string(precedence[i])] {
+			continue
+		}
+		var err error
+		switch precedence[i] {
+		case BindPath:
+			err = bindParams(c.Params, v)
+		case BindQuery:
+			err = bindMap(c.Req.URL.Query(), v, "query")
+		case BindHeader:
+			err = bindMap(c.Req.Header, v, "header")
+		case BindCookie:
+			err = bindCookies(c.Req, v)
+		case BindBody:
+			err = c.bindBody(v)
 		}
-	} else if strings.Contains(contentType, "application/x-www-form-urlencoded") ||
-		strings.Contains(contentType, "multipart/form-data") {
-		if err := bindForm(c.Req, v); err != nil {
+		if err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
-	// Validate the combined result
-	return validateStruct(v)
+// bindBody binds a form or JSON request body to v. A missing body is not
+// an error.
+func (c *Context) bindBody(v any) error {
+	if !hasBody(c.Req) {
+		return nil
+	}
+	if isFormMediaType(mediaType(c.Header("Content-Type"))) {
+		return bindForm(c.Req, v, c.maxMultipartMemory())
+	}
+	if err := decodeJSONBody(c.Req.Body, v, c.disallowUnknownFields()); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// MustBind is like Bind but aborts the request on failure and reports
+// whether binding succeeded, so handlers can simply return:
+//
+//	var req CreateUserRequest
+//	if !c.MustBind(&req) {
+//		return nil
+//	}
+//
+// Validation failures respond with 422 and per-field errors; any other
+// binding failure responds with 400.
+func (c *Context) MustBind(v any) bool {
+	return c.mustBind(c.Bind(v))
+}
+
+// MustBindJSON is like BindJSON but aborts the request on failure.
+// See MustBind.
+func (c *Context) MustBindJSON(v any) bool {
+	return c.mustBind(c.BindJSON(v))
+}
+
+// MustBindQuery is like BindQuery but aborts the request on failure.
+// See MustBind.
+func (c *Context) MustBindQuery(v any) bool {
+	return c.mustBind(c.BindQuery(v))
+}
+
+// MustBindHeader is like BindHeader but aborts the request on failure.
+// See MustBind.
+func (c *Context) MustBindHeader(v any) bool {
+	return c.mustBind(c.BindHeader(v))
+}
+
+// MustBindPath is like BindPath but aborts the request on failure.
+// See MustBind.
+func (c *Context) MustBindPath(v any) bool {
+	return c.mustBind(c.BindPath(v))
+}
+
+// mustBind aborts the request if err is non-nil and reports whether it was nil.
+func (c *Context) mustBind(err error) bool {
+	if err == nil {
+		return true
+	}
+
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		c.AbortWithError(StatusUnprocessableEntity, validationErrs)
+		return false
+	}
+
+	c.AbortWithError(StatusBadRequest, HumanizeBindError(err))
+	return false
 }
 
 // Cookie returns the named cookie.
@@ -380,21 +1027,63 @@ func (c *Context) SetCookie(cookie *http.Cookie) {
 	http.SetCookie(c.Res, cookie)
 }
 
-// Redirect redirects the request to a new location.
+// Redirect redirects the request to a new location. The code must be a
+// 3xx status; otherwise nothing is written and an error is returned.
 func (c *Context) Redirect(code int, location string) error {
+	if code < http.StatusMultipleChoices || code > http.StatusPermanentRedirect {
+		return fmt.Errorf("ginji: invalid redirect status code %d", code)
+	}
 	http.Redirect(c.Res, c.Req, location, code)
 	return nil
 }
 
+// RedirectToRoute redirects to the route named name, built from params as
+// by Engine.URL. Nothing is written if the URL cannot be built.
+func (c *Context) RedirectToRoute(code int, name string, params map[string]string) error {
+	if c.engine == nil {
+		return fmt.Errorf("ginji: no route named %q", name)
+	}
+	location, err := c.engine.URL(name, params)
+	if err != nil {
+		return err
+	}
+	return c.Redirect(code, location)
+}
+
 // FormValue returns the form value for the given key.
 func (c *Context) FormValue(key string) string {
+	_ = parseForm(c.Req, c.maxMultipartMemory())
 	return c.Req.FormValue(key)
 }
 
 // FormFile returns the file for the given key.
+// Multipart data beyond the engine's MaxMultipartMemory is spilled to disk.
 func (c *Context) FormFile(key string) (*multipart.FileHeader, error) {
-	_, fileHeader, err := c.Req.FormFile(key)
-	return fileHeader, err
+	if err := parseForm(c.Req, c.maxMultipartMemory()); err != nil {
+		return nil, err
+	}
+	file, fileHeader, err := c.Req.FormFile(key)
+	if err != nil {
+		return nil, err
+	}
+	_ = file.Close()
+	return fileHeader, nil
+}
+
+// maxMultipartMemory returns the memory limit used when parsing multipart forms.
+func (c *Context) maxMultipartMemory() int64 {
+	if c.engine != nil && c.engine.MaxMultipartMemory > 0 {
+		return c.engine.MaxMultipartMemory
+	}
+	return defaultMaxMultipartMemory
+}
+
+// maxDecompressedSize returns the limit for decompressed request bodies.
+func (c *Context) maxDecompressedSize() int64 {
+	if c.engine != nil && c.engine.MaxDecompressedSize > 0 {
+		return c.engine.MaxDecompressedSize
+	}
+	return defaultMaxDecompressedSize
 }
 
 // Error sets an error and marks the context for error handling.
@@ -404,13 +1093,19 @@ func (c *Context) Error(err error) *Context {
 }
 
 // AbortWithError aborts the request with an error.
+// ValidationErrors keep their field-level details, which the default error
+// handler renders in ErrorResponse.Errors.
 func (c *Context) AbortWithError(code int, err error) {
 	c.aborted = true
 	var httpErr *HTTPError
 	if he, ok := err.(*HTTPError); ok {
 		httpErr = he
+	} else if ve, ok := err.(ValidationErrors); ok {
+		httpErr = NewHTTPError(code, "Validation failed")
+		httpErr.internal = ve
 	} else {
 		httpErr = NewHTTPError(code, err.Error())
+		httpErr.internal = err
 	}
 	handleError(c, httpErr)
 	c.Abort()
@@ -419,7 +1114,6 @@ func (c *Context) AbortWithError(code int, err error) {
 // AbortWithStatusJSON aborts the request with a JSON response.
 func (c *Context) AbortWithStatusJSON(code int, data any) {
 	c.aborted = true
-	c.Status(code)
 	_ = c.JSON(code, data)
 	c.Abort()
 }
@@ -437,12 +1131,21 @@ func (c *Context) IsAborted() bool {
 
 // Next executes the next handler in the middleware chain.
 // It now returns error to allow middleware to handle errors properly.
+// Once a handler has written the response and returned, the remaining
+// handlers are skipped, so a middleware that answers a request itself
+// need not call Abort.
 func (c *Context) Next() error {
 	c.index++
 	for c.index < int8(len(c.handlers)) {
 		if err := c.handlers[c.index](c); err != nil {
 			return err
 		}
+		// A handler that wrote the response, or only set its status, and
+		// returned without calling Next ends the chain, as if it had
+		// called Abort
+		if c.Written() {
+			return nil
+		}
 		c.index++
 	}
 	return nil
@@ -527,3 +1230,52 @@ func MustGetServiceTyped[T any](c *Context, name string) T {
 	}
 	return service
 }
+
+// Value returns the value stored under key with type T. The second result
+// is false if the key is missing or holds a value of another type.
+func Value[T any](c *Context, key string) (T, bool) {
+	value, ok := c.Keys[key].(T)
+	return value, ok
+}
+
+// MustValue is like Value but panics if the key is missing or holds a
+// value of another type.
+func MustValue[T any](c *Context, key string) T {
+	raw, exists := c.Keys[key]
+	if !exists {
+		panic("key \"" + key + "\" does not exist")
+	}
+	value, ok := raw.(T)
+	if !ok {
+		panic(fmt.Sprintf("key %q holds %T, not %s", key, raw, reflect.TypeOf((*T)(nil)).Elem()))
+	}
+	return value
+}
+
+// ParamType is the set of types ParamAs can convert path parameters to.
+type ParamType interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~string
+}
+
+// ParamAs returns the path parameter key converted to T. A missing or
+// unconvertible value yields a 400 *HTTPError that handlers can return
+// as is:
+//
+//	id, err := ginji.ParamAs[int64](c, "id")
+//	if err != nil {
+//		return err
+//	}
+func ParamAs[T ParamType](c *Context, key string) (T, error) {
+	var value T
+	raw, ok := c.Params[key]
+	if !ok || raw == "" {
+		return value, NewHTTPError(StatusBadRequest, fmt.Sprintf("missing path parameter %q", key))
+	}
+	if err := setField(reflect.ValueOf(&value).Elem(), raw); err != nil {
+		return value, NewHTTPError(StatusBadRequest,
+			fmt.Sprintf("invalid path parameter %q: %q is not a valid %s", key, raw, reflect.TypeOf(value)))
+	}
+	return value, nil
+}
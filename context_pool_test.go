@@ -276,3 +276,42 @@ func BenchmarkContextSetGet(b *testing.B) {
 		_, _ = ctx.Get("key")
 	}
 }
+
+// TestEngineInFlight tests the in-flight request counter
+func TestEngineInFlight(t *testing.T) {
+	app := New()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	app.Get("/slow", func(c *Context) error {
+		started <- struct{}{}
+		<-release
+		return c.Text(200, "ok")
+	})
+
+	if app.InFlight() != 0 {
+		t.Fatalf("Expected 0 in-flight requests, got %d", app.InFlight())
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+		}()
+		<-started
+	}
+
+	if app.InFlight() != 3 {
+		t.Errorf("Expected 3 in-flight requests, got %d", app.InFlight())
+	}
+
+	close(release)
+	wg.Wait()
+
+	if app.InFlight() != 0 {
+		t.Errorf("Expected 0 in-flight requests after draining, got %d", app.InFlight())
+	}
+}
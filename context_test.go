@@ -1,9 +1,18 @@
 package ginji
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 type TestStruct struct {
@@ -48,3 +57,555 @@ func TestBindHeader(t *testing.T) {
 		t.Errorf("Expected ginji-header, got %s", w.Body.String())
 	}
 }
+
+type ctxKey struct{}
+
+func TestContextImplementsContext(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "from-request"), time.Minute)
+	req := httptest.NewRequest("GET", "/", nil).WithContext(parent)
+	c := NewTestContext(httptest.NewRecorder(), req)
+	c.Set("user", "alice")
+
+	var ctx context.Context = c
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("Expected deadline from request context")
+	}
+	if ctx.Value("user") != "alice" {
+		t.Errorf("Expected Keys lookup, got %v", ctx.Value("user"))
+	}
+	if ctx.Value(ctxKey{}) != "from-request" {
+		t.Errorf("Expected request context value, got %v", ctx.Value(ctxKey{}))
+	}
+	if ctx.Err() != nil {
+		t.Errorf("Expected no error before cancel, got %v", ctx.Err())
+	}
+
+	cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected Done to be closed after cancel")
+	}
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", ctx.Err())
+	}
+}
+
+func TestContextResetRebindsRequestContext(t *testing.T) {
+	app := New()
+	app.Get("/", func(c *Context) error {
+		if c.Err() != nil {
+			return c.Text(http.StatusInternalServerError, "stale context")
+		}
+		return c.Text(http.StatusOK, "ok")
+	})
+
+	// A canceled request must not leak into the next pooled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestServerTiming(t *testing.T) {
+	app := New()
+	app.Use(func(c *Context) error {
+		c.ServerTiming("mw", 5*time.Millisecond, "")
+		return c.Next()
+	})
+	app.Get("/", func(c *Context) error {
+		c.ServerTiming("db", 1500*time.Microsecond, "users query")
+		if err := c.Text(http.StatusOK, "ok"); err != nil {
+			return err
+		}
+		// Too late: headers have already been sent
+		c.ServerTiming("late", time.Millisecond, "")
+		return nil
+	})
+
+	w := PerformRequest(app, "GET", "/", nil)
+
+	expected := `mw;dur=5, db;dur=1.5;desc="users query"`
+	if got := w.Header().Get("Server-Timing"); got != expected {
+		t.Errorf("Expected Server-Timing %q, got %q", expected, got)
+	}
+
+	// Entries must not leak into the next pooled context
+	app.Get("/plain", func(c *Context) error {
+		return c.Text(http.StatusOK, "ok")
+	})
+	w = PerformRequest(app, "GET", "/plain", nil)
+	if got := w.Header().Get("Server-Timing"); got != "mw;dur=5" {
+		t.Errorf("Expected only middleware timing, got %q", got)
+	}
+}
+
+func TestBlobAndReader(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}
+
+	app := New()
+	app.Get("/blob", func(c *Context) error {
+		return c.Blob(http.StatusOK, "image/png", png)
+	})
+	app.Get("/reader", func(c *Context) error {
+		return c.Reader(http.StatusCreated, "application/pdf", bytes.NewReader([]byte("%PDF-1.7 trailing")), 8)
+	})
+
+	w := PerformRequest(app, "GET", "/blob", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Expected Content-Type image/png, got %s", w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("Content-Length") != "8" {
+		t.Errorf("Expected Content-Length 8, got %s", w.Header().Get("Content-Length"))
+	}
+	if !bytes.Equal(w.Body.Bytes(), png) {
+		t.Errorf("Expected PNG bytes, got %v", w.Body.Bytes())
+	}
+
+	w = PerformRequest(app, "GET", "/reader", nil)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/pdf" || w.Header().Get("Content-Length") != "8" {
+		t.Errorf("Expected PDF headers with length 8, got %v", w.Header())
+	}
+	if w.Body.String() != "%PDF-1.7" {
+		t.Errorf("Expected body limited to length, got %q", w.Body.String())
+	}
+}
+
+func TestTypedValue(t *testing.T) {
+	type user struct{ Name string }
+
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), New())
+	c.Set("user", &user{Name: "ann"})
+	c.Set("count", 3)
+
+	u, ok := Value[*user](c, "user")
+	if !ok || u.Name != "ann" {
+		t.Errorf("Expected typed user, got %v, %v", u, ok)
+	}
+	if _, ok := Value[string](c, "count"); ok {
+		t.Error("Expected type mismatch to report false")
+	}
+	if _, ok := Value[int](c, "missing"); ok {
+		t.Error("Expected missing key to report false")
+	}
+	if n := MustValue[int](c, "count"); n != 3 {
+		t.Errorf("Expected 3, got %d", n)
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(fmt.Sprint(r), "not string") {
+			t.Errorf("Expected type mismatch panic, got %v", r)
+		}
+	}()
+	MustValue[string](c, "count")
+}
+
+func TestAddVary(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/", nil), New())
+
+	c.SetHeader("Vary", "Accept-Encoding")
+	c.AddVary("Accept", "accept-encoding")
+	c.AddVary("Origin")
+	if got := w.Header().Get("Vary"); got != "Accept-Encoding, Accept, Origin" {
+		t.Errorf("Expected merged Vary header, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	c = NewContext(w, httptest.NewRequest("GET", "/", nil), New())
+	c.SetHeader("Vary", "*")
+	c.AddVary("Accept")
+	if got := w.Header().Get("Vary"); got != "*" {
+		t.Errorf("Expected Vary * to be kept as is, got %q", got)
+	}
+}
+
+func TestMiddlewareVaryHeadersMerge(t *testing.T) {
+	app := New()
+	app.Use(Compress())
+	app.Get("/data", func(c *Context) error {
+		return c.Negotiate(http.StatusOK, map[string]string{"a": "b"}, NegotiateFormat{})
+	})
+
+	req := httptest.NewRequest("GET", "/data", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Vary"); got != "Accept-Encoding, Accept" {
+		t.Errorf("Expected Vary from both middlewares, got %q", got)
+	}
+}
+
+func TestParamAs(t *testing.T) {
+	type userID uint32
+
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), New())
+	c.Params = map[string]string{"id": "42", "slug": "hello", "neg": "-1"}
+
+	if id, err := ParamAs[int](c, "id"); err != nil || id != 42 {
+		t.Errorf("Expected 42, got %d, %v", id, err)
+	}
+	if id, err := ParamAs[userID](c, "id"); err != nil || id != 42 {
+		t.Errorf("Expected named uint 42, got %d, %v", id, err)
+	}
+	if slug, err := ParamAs[string](c, "slug"); err != nil || slug != "hello" {
+		t.Errorf("Expected hello, got %q, %v", slug, err)
+	}
+
+	_, err := ParamAs[uint](c, "neg")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Code != StatusBadRequest || !strings.Contains(httpErr.Message, `"neg"`) {
+		t.Errorf("Expected 400 conversion error for neg, got %v", err)
+	}
+	if _, err := ParamAs[int64](c, "slug"); err == nil || !strings.Contains(err.Error(), "int64") {
+		t.Errorf("Expected conversion error naming int64, got %v", err)
+	}
+	if _, err := ParamAs[int](c, "missing"); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected missing parameter error, got %v", err)
+	}
+}
+
+func TestJSONCanonicalAndEscapeHTML(t *testing.T) {
+	defer SetJSONCanonical(false)
+	defer SetJSONEscapeHTML(true)
+
+	type payload struct {
+		Zeta  string         `json:"zeta"`
+		Alpha float64        `json:"alpha"`
+		Meta  map[string]int `json:"meta"`
+	}
+	v := payload{Zeta: "<b>", Alpha: 1.50, Meta: map[string]int{"b": 2, "a": 1}}
+
+	render := func() string {
+		w := httptest.NewRecorder()
+		c := NewContext(w, httptest.NewRequest("GET", "/", nil), New())
+		if err := c.JSON(http.StatusOK, v); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return w.Body.String()
+	}
+
+	if got := render(); got != `{"zeta":"\u003cb\u003e","alpha":1.5,"meta":{"a":1,"b":2}}`+"\n" {
+		t.Errorf("Expected default encoding, got %s", got)
+	}
+
+	SetJSONCanonical(true)
+	SetJSONEscapeHTML(false)
+	if got := render(); got != `{"alpha":1.5,"meta":{"a":1,"b":2},"zeta":"<b>"}`+"\n" {
+		t.Errorf("Expected canonical encoding, got %s", got)
+	}
+}
+
+func TestExpectContinue(t *testing.T) {
+	app := New()
+	app.Use(func(c *Context) error {
+		if c.ExpectsContinue() && c.Req.ContentLength > 10 {
+			c.AbortWithError(http.StatusRequestEntityTooLarge, NewHTTPError(http.StatusRequestEntityTooLarge))
+			return nil
+		}
+		return c.Next()
+	})
+	app.Post("/upload", func(c *Context) error {
+		c.Continue()
+		body, err := io.ReadAll(c.Req.Body)
+		if err != nil {
+			return err
+		}
+		return c.Text(http.StatusCreated, string(body))
+	})
+
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	send := func(body string) (string, *bufio.Reader, net.Conn) {
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		fmt.Fprintf(conn, "POST /upload HTTP/1.1\r\nHost: test\r\nExpect: 100-continue\r\nContent-Length: %d\r\n\r\n", len(body))
+		r := bufio.NewReader(conn)
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read status line: %v", err)
+		}
+		return line, r, conn
+	}
+
+	// Rejected before the body is sent: the final status comes first
+	line, _, conn := send(strings.Repeat("x", 100))
+	conn.Close()
+	if !strings.Contains(line, "413") {
+		t.Errorf("Expected 413 without 100 Continue, got %q", line)
+	}
+
+	// Accepted: 100 Continue, then the final response once the body arrives
+	line, r, conn := send("hello")
+	defer conn.Close()
+	if !strings.Contains(line, "100 Continue") {
+		t.Fatalf("Expected 100 Continue, got %q", line)
+	}
+	if _, err := r.ReadString('\n'); err != nil { // blank line ending the interim response
+		t.Fatal(err)
+	}
+	conn.Write([]byte("hello"))
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusCreated || string(body) != "hello" {
+		t.Errorf("Expected 201 hello, got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestRoutePattern(t *testing.T) {
+	app := New()
+	var pattern string
+	app.Use(func(c *Context) error {
+		err := c.Next()
+		pattern = c.RoutePattern()
+		return err
+	})
+	app.Get("/users/:id/files/*path", func(c *Context) error { return nil })
+
+	PerformRequest(app, "GET", "/users/1/files/a/b", nil)
+	if pattern != "/users/:id/files/*path" {
+		t.Errorf("Expected matched pattern, got %q", pattern)
+	}
+
+	PerformRequest(app, "GET", "/missing", nil)
+	if pattern != "" {
+		t.Errorf("Expected empty pattern for unmatched route, got %q", pattern)
+	}
+}
+
+func TestContextCopy(t *testing.T) {
+	app := New()
+	copied := make(chan *Context, 1)
+	app.Get("/users/:id", func(c *Context) error {
+		c.Set("user", "alice")
+		cp := c.Copy()
+		c.Set("user", "bob")
+		copied <- cp
+		return c.Text(StatusOK, "ok")
+	})
+
+	w := PerformRequest(app, "GET", "/users/42", nil)
+	cp := <-copied
+
+	// The original context has been returned to the pool by now
+	if cp.Param("id") != "42" || cp.RoutePattern() != "/users/:id" {
+		t.Errorf("Expected copied route data, got id %q pattern %q", cp.Param("id"), cp.RoutePattern())
+	}
+	if user, _ := cp.Get("user"); user != "alice" {
+		t.Errorf("Expected Keys copied at the time of Copy, got %v", user)
+	}
+	if cp.Err() != nil {
+		t.Errorf("Expected copy's context to outlive the request, got %v", cp.Err())
+	}
+
+	// Writes through the copy never reach the original response
+	_ = cp.Text(StatusInternalServerError, "late")
+	if w.Code != StatusOK || w.Body.String() != "ok" {
+		t.Errorf("Expected original response untouched, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	app := New()
+	if err := app.SetTrustedProxies("10.0.0.0/8", "::1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.SetTrustedProxies("not-an-ip"); err == nil {
+		t.Error("Expected error for invalid proxy")
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		realIP    string
+		expected  string
+	}{
+		{"direct", "203.0.113.1:1234", "", "", "203.0.113.1"},
+		{"untrusted proxy headers ignored", "203.0.113.1:1234", "198.51.100.1", "198.51.100.2", "203.0.113.1"},
+		{"trusted proxy", "10.0.0.1:1234", "198.51.100.1", "", "198.51.100.1"},
+		{"proxy chain", "10.0.0.1:1234", "1.1.1.1, 198.51.100.1, 10.0.0.2", "", "198.51.100.1"},
+		{"real ip fallback", "[::1]:1234", "", "198.51.100.3", "198.51.100.3"},
+		{"invalid forwarded", "10.0.0.1:1234", "unknown", "", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			c := NewContext(httptest.NewRecorder(), req, app)
+			if ip := c.ClientIP(); ip != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, ip)
+			}
+		})
+	}
+}
+
+func TestContextKeyedValues(t *testing.T) {
+	type dbKey struct{}
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), New())
+
+	c.Set("db", "user value")
+	c.SetKeyed(dbKey{}, "middleware value")
+	if v, _ := c.Get("db"); v != "user value" {
+		t.Errorf("Expected string key to be unaffected, got %v", v)
+	}
+	if v, ok := c.GetKeyed(dbKey{}); !ok || v != "middleware value" {
+		t.Errorf("Expected typed key value, got %v", v)
+	}
+	if c.Value(dbKey{}) != "middleware value" {
+		t.Errorf("Expected Value to find typed key, got %v", c.Value(dbKey{}))
+	}
+
+	// String keys go through Keys either way
+	c.SetKeyed("name", "alice")
+	if c.GetString("name") != "alice" {
+		t.Errorf("Expected SetKeyed string key in Keys, got %q", c.GetString("name"))
+	}
+	if v, ok := c.GetKeyed("db"); !ok || v != "user value" {
+		t.Errorf("Expected GetKeyed to read string keys, got %v", v)
+	}
+
+	cp := c.Copy()
+	c.SetKeyed(dbKey{}, "changed")
+	if v, _ := cp.GetKeyed(dbKey{}); v != "middleware value" {
+		t.Errorf("Expected copy to keep its own keyed values, got %v", v)
+	}
+
+	c.Reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	if _, ok := c.GetKeyed(dbKey{}); ok {
+		t.Error("Expected keyed values to be cleared on reset")
+	}
+}
+
+func TestContextDeepCopy(t *testing.T) {
+	app := New()
+	app.Get("/users/:id", func(c *Context) error {
+		c.Set("user", "alice")
+		cp := c.DeepCopy()
+		c.Set("user", "bob")
+		if user, _ := cp.Get("user"); user != "alice" || cp.Request.Param("id") != "42" {
+			t.Errorf("Expected own copies of Keys and Params, got %v %q", user, cp.Request.Param("id"))
+		}
+		// Unlike Copy, the deep copy writes the live response
+		return cp.Text(StatusCreated, "from copy")
+	})
+
+	w := PerformRequest(app, "GET", "/users/42", nil)
+	if w.Code != StatusCreated || w.Body.String() != "from copy" {
+		t.Errorf("Expected response written through the deep copy, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRequestIDAccessor(t *testing.T) {
+	app := New()
+	app.Use(RequestID())
+	app.Get("/", func(c *Context) error {
+		// A user value under the legacy string key cannot clobber the ID
+		c.Set("request_id", "user value")
+		return c.Text(http.StatusOK, c.RequestID())
+	})
+
+	w := PerformRequest(app, "GET", "/", nil)
+	if id := w.Header().Get("X-Request-ID"); id == "" || w.Body.String() != id {
+		t.Errorf("Expected RequestID %q, got %q", id, w.Body.String())
+	}
+}
+
+func TestStatusDefersWriteHeader(t *testing.T) {
+	app := New()
+	app.Get("/header-after-status", func(c *Context) error {
+		c.Status(http.StatusCreated)
+		c.SetHeader("Location", "/items/1")
+		return c.Send([]byte("created"))
+	})
+	app.Get("/no-body", func(c *Context) error {
+		c.Status(http.StatusNoContent)
+		c.SetHeader("X-Done", "yes")
+		return nil
+	})
+	app.Get("/status-then-json", func(c *Context) error {
+		c.Status(http.StatusAccepted)
+		return c.JSON(http.StatusAccepted, H{"ok": true})
+	})
+
+	server := httptest.NewServer(app)
+	defer server.Close()
+	get := func(path string) *http.Response {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	resp := get("/header-after-status")
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Location") != "/items/1" {
+		t.Errorf("Expected 201 with Location, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	resp = get("/no-body")
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("X-Done") != "yes" {
+		t.Errorf("Expected 204 with X-Done, got %d %q", resp.StatusCode, resp.Header.Get("X-Done"))
+	}
+	resp = get("/status-then-json")
+	if resp.StatusCode != http.StatusAccepted || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		t.Errorf("Expected 202 JSON, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestStatusWithoutBodyIsFinal(t *testing.T) {
+	app := New()
+	app.Use(func(c *Context) error {
+		if c.Header("Authorization") == "" {
+			c.Status(http.StatusUnauthorized)
+			return nil
+		}
+		return c.Next()
+	})
+	app.Get("/secret", func(c *Context) error {
+		return c.Text(http.StatusOK, "secret")
+	})
+
+	w := PerformRequest(app, "GET", "/secret", nil)
+	if w.Code != http.StatusUnauthorized || w.Body.String() != "" {
+		t.Errorf("Expected bare 401, got %d %q", w.Code, w.Body.String())
+	}
+
+	// Within one handler, a later status does not replace the first either
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
+	c.Status(http.StatusForbidden)
+	if !c.Written() {
+		t.Error("Expected a set status to count as written")
+	}
+	_ = c.Text(http.StatusOK, "ok")
+	if c.writer.status != http.StatusForbidden {
+		t.Errorf("Expected status 403 to stick, got %d", c.writer.status)
+	}
+}
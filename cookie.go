@@ -0,0 +1,114 @@
+package ginji
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CookieBuilder builds a cookie with a fluent API and validates its
+// attributes before writing it. Create one with Context.NewCookie.
+type CookieBuilder struct {
+	c      *Context
+	cookie http.Cookie
+}
+
+// NewCookie starts building a cookie with the given name and value.
+// The path defaults to "/".
+func (c *Context) NewCookie(name, value string) *CookieBuilder {
+	return &CookieBuilder{
+		c:      c,
+		cookie: http.Cookie{Name: name, Value: value, Path: "/"},
+	}
+}
+
+// Path sets the cookie path.
+func (b *CookieBuilder) Path(path string) *CookieBuilder {
+	b.cookie.Path = path
+	return b
+}
+
+// Domain sets the cookie domain.
+func (b *CookieBuilder) Domain(domain string) *CookieBuilder {
+	b.cookie.Domain = domain
+	return b
+}
+
+// MaxAge sets the cookie lifetime. A negative duration deletes the cookie.
+func (b *CookieBuilder) MaxAge(d time.Duration) *CookieBuilder {
+	if d < 0 {
+		b.cookie.MaxAge = -1
+	} else {
+		b.cookie.MaxAge = int(d.Seconds())
+	}
+	return b
+}
+
+// Expires sets the cookie expiry time.
+func (b *CookieBuilder) Expires(t time.Time) *CookieBuilder {
+	b.cookie.Expires = t
+	return b
+}
+
+// Secure marks the cookie as HTTPS only.
+func (b *CookieBuilder) Secure() *CookieBuilder {
+	b.cookie.Secure = true
+	return b
+}
+
+// HttpOnly hides the cookie from JavaScript.
+func (b *CookieBuilder) HttpOnly() *CookieBuilder {
+	b.cookie.HttpOnly = true
+	return b
+}
+
+// SameSite sets the SameSite attribute.
+func (b *CookieBuilder) SameSite(mode http.SameSite) *CookieBuilder {
+	b.cookie.SameSite = mode
+	return b
+}
+
+// Partitioned marks the cookie for partitioned storage (CHIPS).
+func (b *CookieBuilder) Partitioned() *CookieBuilder {
+	b.cookie.Partitioned = true
+	return b
+}
+
+// Cookie validates and returns the built cookie without writing it.
+func (b *CookieBuilder) Cookie() (*http.Cookie, error) {
+	if err := validateCookie(&b.cookie); err != nil {
+		return nil, err
+	}
+	cookie := b.cookie
+	return &cookie, nil
+}
+
+// Set validates the cookie and adds it to the response.
+func (b *CookieBuilder) Set() error {
+	cookie, err := b.Cookie()
+	if err != nil {
+		return err
+	}
+	b.c.SetCookie(cookie)
+	return nil
+}
+
+// validateCookie checks attribute combinations that browsers reject.
+func validateCookie(cookie *http.Cookie) error {
+	if err := cookie.Valid(); err != nil {
+		return err
+	}
+	if cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure {
+		return errors.New("cookie: SameSite=None requires Secure")
+	}
+	if strings.HasPrefix(cookie.Name, "__Secure-") && !cookie.Secure {
+		return errors.New("cookie: __Secure- prefix requires Secure")
+	}
+	if strings.HasPrefix(cookie.Name, "__Host-") {
+		if !cookie.Secure || cookie.Path != "/" || cookie.Domain != "" {
+			return errors.New("cookie: __Host- prefix requires Secure, Path=/ and no Domain")
+		}
+	}
+	return nil
+}
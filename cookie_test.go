@@ -0,0 +1,64 @@
+package ginji
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCookieBuilder(t *testing.T) {
+	app := New()
+	app.Get("/login", func(c *Context) error {
+		return c.NewCookie("session", "abc").
+			MaxAge(time.Hour).
+			Secure().
+			HttpOnly().
+			SameSite(http.SameSiteNoneMode).
+			Partitioned().
+			Set()
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/login", nil))
+
+	header := w.Header().Get("Set-Cookie")
+	for _, attr := range []string{"session=abc", "Path=/", "Max-Age=3600", "HttpOnly", "Secure", "SameSite=None", "Partitioned"} {
+		if !strings.Contains(header, attr) {
+			t.Errorf("Expected %s in Set-Cookie, got %s", attr, header)
+		}
+	}
+}
+
+func TestCookieBuilderValidation(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), New())
+
+	tests := []struct {
+		name    string
+		builder *CookieBuilder
+		wantErr string
+	}{
+		{"SameSite=None without Secure", c.NewCookie("a", "1").SameSite(http.SameSiteNoneMode), "SameSite=None requires Secure"},
+		{"Partitioned without Secure", c.NewCookie("a", "1").Partitioned(), "Secure"},
+		{"__Secure- without Secure", c.NewCookie("__Secure-a", "1"), "__Secure- prefix"},
+		{"__Host- with Domain", c.NewCookie("__Host-a", "1").Secure().Domain("example.com"), "__Host- prefix"},
+		{"invalid name", c.NewCookie("bad name", "1"), "invalid"},
+		{"valid __Host-", c.NewCookie("__Host-a", "1").Secure(), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Cookie()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package ginji
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// CSV writes records as a text/csv response. Unless the handler has set
+// its own Content-Disposition, the response is offered as a download named
+// after the last segment of the request path, e.g. "users.csv" for
+// /export/users.
+func (c *Context) CSV(code int, records [][]string) error {
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return err
+	}
+
+	c.setCSVHeaders()
+	c.Status(code)
+	return c.Send(buf.Bytes())
+}
+
+// CSVStruct writes rows, a slice of structs or struct pointers, as a
+// text/csv response with a header row. Columns are named by the `csv` tag
+// of each exported field, falling back to the field name; fields tagged
+// "-" are skipped. Values implementing encoding.TextMarshaler format
+// themselves and nil pointer fields become empty cells. Nil rows in a slice
+// of struct pointers are skipped, and a nil or empty slice writes only the
+// header row. Rows of any other type are rejected with an error before
+// anything is written.
+func (c *Context) CSVStruct(code int, rows any) error {
+	records, err := csvRecords(rows)
+	if err != nil {
+		return err
+	}
+	return c.CSV(code, records)
+}
+
+// BindCSV binds a CSV request body with a header row into v, a pointer to
+// a slice of structs, and validates the result. Columns are matched to
+// fields like CSVStruct names them, case-insensitively; unknown columns
+// are ignored. Conversion failures are reported as BindFieldErrors named
+// after the row and column, e.g. "row 3 age".
+func (c *Context) BindCSV(v any) error {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Slice {
+		return errors.New("BindCSV requires a pointer to a slice of structs")
+	}
+	slice := ptr.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return errors.New("BindCSV requires a pointer to a slice of structs")
+	}
+
+	r := csv.NewReader(c.Req.Body)
+	header, err := r.Read()
+	if err != nil {
+		return err
+	}
+	fields := csvFields(structType)
+	columns := make([]*csvField, len(header))
+	for i, name := range header {
+		for j := range fields {
+			if strings.EqualFold(strings.TrimSpace(name), fields[j].name) {
+				columns[i] = &fields[j]
+				break
+			}
+		}
+	}
+
+	var fieldErrs BindFieldErrors
+	rows := reflect.MakeSlice(slice.Type(), 0, 0)
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		row := reflect.New(structType).Elem()
+		for i, value := range record {
+			if i >= len(columns) || columns[i] == nil || value == "" {
+				continue
+			}
+			field, err := row.FieldByIndexErr(columns[i].index)
+			if err != nil {
+				continue
+			}
+			if err := setStructField(field, columns[i].field, value); err != nil {
+				key := "row " + strconv.Itoa(line) + " " + columns[i].name
+				fieldErrs = append(fieldErrs, newBindFieldError(key, "csv", value, field, err))
+			}
+		}
+		if elemType.Kind() == reflect.Ptr {
+			row = row.Addr()
+		}
+		rows = reflect.Append(rows, row)
+	}
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+
+	slice.Set(rows)
+	return c.validate(v)
+}
+
+// setCSVHeaders sets the CSV content type and a default download name.
+func (c *Context) setCSVHeaders() {
+	c.SetHeader("Content-Type", "text/csv; charset=utf-8")
+	if c.Res.Header().Get("Content-Disposition") != "" {
+		return
+	}
+	name := path.Base(c.Req.URL.Path)
+	if name == "/" || name == "." {
+		name = "export"
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".csv") {
+		name += ".csv"
+	}
+	c.SetHeader("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, sanitizeFilename(name)))
+}
+
+// csvField is a struct field written to or read from a CSV column.
+type csvField struct {
+	name  string
+	index []int
+	field reflect.StructField
+}
+
+// csvFields returns the CSV columns of struct type t in field order,
+// including the promoted fields of embedded structs.
+func csvFields(t reflect.Type) []csvField {
+	var fields []csvField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || (f.Anonymous && f.Tag.Get("csv") == "") {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("csv"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, csvField{name: name, index: f.Index, field: f})
+	}
+	return fields
+}
+
+// csvRecords converts a slice of structs into CSV records, header first.
+func csvRecords(rows any) ([][]string, error) {
+	val := reflect.ValueOf(rows)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, fmt.Errorf("CSVStruct requires a slice of structs, got %T", rows)
+	}
+	elemType := val.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("CSVStruct requires a slice of structs, got %T", rows)
+	}
+
+	fields := csvFields(elemType)
+	records := make([][]string, 0, val.Len()+1)
+	header := make([]string, len(fields))
+	for i, f := range fields {
+		header[i] = f.name
+	}
+	records = append(records, header)
+
+	for i := 0; i < val.Len(); i++ {
+		row := val.Index(i)
+		if row.Kind() == reflect.Ptr {
+			if row.IsNil() {
+				continue
+			}
+			row = row.Elem()
+		}
+		record := make([]string, len(fields))
+		for j, f := range fields {
+			field, err := row.FieldByIndexErr(f.index)
+			if err != nil {
+				continue
+			}
+			cell, err := csvValue(field)
+			if err != nil {
+				return nil, fmt.Errorf("failed to format field %s: %w", f.field.Name, err)
+			}
+			record[j] = cell
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// csvValue formats a field value as a CSV cell.
+func csvValue(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			return string(text), err
+		}
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
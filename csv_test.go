@@ -0,0 +1,126 @@
+package ginji
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type csvAudit struct {
+	CreatedAt time.Time `csv:"created_at"`
+}
+
+type csvUser struct {
+	ID    int     `csv:"id"`
+	Name  string  `csv:"name" validate:"required"`
+	Email *string `csv:"email"`
+	Token string  `csv:"-"`
+	csvAudit
+}
+
+func TestCSV(t *testing.T) {
+	app := New()
+	app.Get("/export/report", func(c *Context) error {
+		return c.CSV(http.StatusOK, [][]string{{"a", "b"}, {"1", "x,y"}})
+	})
+	app.Get("/export/named", func(c *Context) error {
+		c.SetHeader("Content-Disposition", `attachment; filename="custom.csv"`)
+		return c.CSV(http.StatusOK, [][]string{{"a"}})
+	})
+
+	w := PerformRequest(app, "GET", "/export/report", nil)
+	AssertStatus(t, w, http.StatusOK)
+	AssertHeader(t, w, "Content-Type", "text/csv; charset=utf-8")
+	AssertHeader(t, w, "Content-Disposition", `attachment; filename="report.csv"`)
+	if w.Body.String() != "a,b\n1,\"x,y\"\n" {
+		t.Errorf("Expected quoted CSV, got %q", w.Body.String())
+	}
+
+	w = PerformRequest(app, "GET", "/export/named", nil)
+	AssertHeader(t, w, "Content-Disposition", `attachment; filename="custom.csv"`)
+}
+
+func TestCSVStruct(t *testing.T) {
+	email := "jane@example.com"
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	app := New()
+	app.Get("/users.csv", func(c *Context) error {
+		return c.CSVStruct(http.StatusOK, []*csvUser{
+			{ID: 1, Name: "Jane", Email: &email, Token: "secret", csvAudit: csvAudit{CreatedAt: created}},
+			{ID: 2, Name: "John"},
+		})
+	})
+
+	w := PerformRequest(app, "GET", "/users.csv", nil)
+	AssertHeader(t, w, "Content-Disposition", `attachment; filename="users.csv"`)
+	expected := "id,name,email,created_at\n" +
+		"1,Jane,jane@example.com,2024-01-02T03:04:05Z\n" +
+		"2,John,,0001-01-01T00:00:00Z\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, w.Body.String())
+	}
+
+	// Nil rows are skipped; a nil slice still writes the header
+	rec := httptest.NewRecorder()
+	c := NewContext(rec, httptest.NewRequest("GET", "/users.csv", nil), nil)
+	if err := c.CSVStruct(http.StatusOK, []*csvUser{nil, {ID: 3, Name: "Ann"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(rec.Body.String(), "\n3,Ann,,0001-01-01T00:00:00Z\n") || strings.Count(rec.Body.String(), "\n") != 2 {
+		t.Errorf("Expected nil row to be skipped, got %q", rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	c = NewContext(rec, httptest.NewRequest("GET", "/users.csv", nil), nil)
+	if err := c.CSVStruct(http.StatusOK, []csvUser(nil)); err != nil || rec.Body.String() != "id,name,email,created_at\n" {
+		t.Errorf("Expected header only for nil slice, got %q %v", rec.Body.String(), err)
+	}
+
+	// Unsupported rows are rejected before anything is written
+	rec = httptest.NewRecorder()
+	c = NewContext(rec, httptest.NewRequest("GET", "/bad", nil), nil)
+	if err := c.CSVStruct(http.StatusOK, []int{1}); err == nil || !strings.Contains(err.Error(), "slice of structs") {
+		t.Errorf("Expected error for unsupported rows, got %v", err)
+	}
+	if c.Written() {
+		t.Error("Expected nothing to be written for unsupported rows")
+	}
+}
+
+func TestBindCSV(t *testing.T) {
+	body := "ID,name,email,extra\n1,Jane,jane@example.com,x\n2,John,,y\n"
+	req := httptest.NewRequest("POST", "/import", strings.NewReader(body))
+	c := NewContext(httptest.NewRecorder(), req, nil)
+
+	var users []csvUser
+	if err := c.BindCSV(&users); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("Expected 2 users, got %d", len(users))
+	}
+	if users[0].ID != 1 || users[0].Name != "Jane" || users[0].Email == nil || *users[0].Email != "jane@example.com" {
+		t.Errorf("Unexpected first user: %+v", users[0])
+	}
+	if users[1].Email != nil {
+		t.Errorf("Expected empty cell to leave email nil, got %v", *users[1].Email)
+	}
+
+	// Conversion errors name the row and column
+	req = httptest.NewRequest("POST", "/import", strings.NewReader("id,name\nabc,Jane\n"))
+	c = NewContext(httptest.NewRecorder(), req, nil)
+	var fieldErrs BindFieldErrors
+	if err := c.BindCSV(&users); !errors.As(err, &fieldErrs) || fieldErrs[0].Field != "row 2 id" {
+		t.Errorf("Expected bind error for row 2 id, got %v", err)
+	}
+
+	// Rows are validated
+	req = httptest.NewRequest("POST", "/import", strings.NewReader("id,name\n1,\n"))
+	c = NewContext(httptest.NewRecorder(), req, nil)
+	var validationErrs ValidationErrors
+	if err := c.BindCSV(&users); !errors.As(err, &validationErrs) {
+		t.Errorf("Expected validation error, got %v", err)
+	}
+}
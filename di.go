@@ -3,6 +3,7 @@ package ginji
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 )
 
@@ -18,6 +19,20 @@ const (
 	Scoped
 )
 
+// String returns the name of the lifetime.
+func (l ServiceLifetime) String() string {
+	switch l {
+	case Singleton:
+		return "singleton"
+	case Transient:
+		return "transient"
+	case Scoped:
+		return "scoped"
+	default:
+		return fmt.Sprintf("ServiceLifetime(%d)", int(l))
+	}
+}
+
 // ServiceDescriptor describes a registered service.
 type ServiceDescriptor struct {
 	Name     string
@@ -114,6 +129,34 @@ func (c *Container) RegisterInstance(name string, instance any) error {
 	return nil
 }
 
+// ServiceInfo is a read-only summary of a registered service, used for diagnostics.
+type ServiceInfo struct {
+	Name     string
+	Lifetime ServiceLifetime
+	Type     reflect.Type
+}
+
+// List returns a summary of all registered services, sorted by name.
+// Factories and instances are not exposed.
+func (c *Container) List() []ServiceInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	services := make([]ServiceInfo, 0, len(c.services))
+	for _, descriptor := range c.services {
+		services = append(services, ServiceInfo{
+			Name:     descriptor.Name,
+			Lifetime: descriptor.Lifetime,
+			Type:     descriptor.Type,
+		})
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services
+}
+
 // Resolve resolves a service by name.
 func (c *Container) Resolve(name string, scope *ServiceScope) (any, error) {
 	c.mu.RLock()
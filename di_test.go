@@ -324,3 +324,36 @@ func TestContextServiceIntegration(t *testing.T) {
 		t.Error("ILogger was not used in request handler")
 	}
 }
+
+func TestContainerList(t *testing.T) {
+	container := NewContainer()
+	_ = container.RegisterSingleton("repo", NewRepository)
+	_ = container.RegisterScoped("logger", func() ILogger { return &simpleLogger{} })
+	_ = container.RegisterInstance("config", map[string]string{"env": "test"})
+
+	services := container.List()
+	if len(services) != 3 {
+		t.Fatalf("Expected 3 services, got %d", len(services))
+	}
+
+	expected := []struct {
+		name     string
+		lifetime ServiceLifetime
+		typeName string
+	}{
+		{"config", Singleton, "map[string]string"},
+		{"logger", Scoped, "ginji.ILogger"},
+		{"repo", Singleton, "*ginji.Repository"},
+	}
+	for i, exp := range expected {
+		if services[i].Name != exp.name {
+			t.Errorf("Expected service %d to be %s, got %s", i, exp.name, services[i].Name)
+		}
+		if services[i].Lifetime != exp.lifetime {
+			t.Errorf("Expected %s lifetime %s, got %s", exp.name, exp.lifetime, services[i].Lifetime)
+		}
+		if services[i].Type.String() != exp.typeName {
+			t.Errorf("Expected %s type %s, got %s", exp.name, exp.typeName, services[i].Type)
+		}
+	}
+}
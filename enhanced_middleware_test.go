@@ -1,7 +1,13 @@
 package ginji
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -259,3 +265,282 @@ func TestUnlessCondition(t *testing.T) {
 		t.Error("Expected Unless middleware to run for non-matching path")
 	}
 }
+
+func TestOnPanicHook(t *testing.T) {
+	app := New()
+	app.Use(Recovery())
+
+	var recovered any
+	var stack string
+	wroteBeforeHook := true
+	app.OnPanic(func(c *Context, r any, s string) {
+		recovered = r
+		stack = s
+		wroteBeforeHook = c.Written()
+	})
+	// A panicking hook must not take down the request
+	app.OnPanic(func(c *Context, r any, s string) {
+		panic("hook failure")
+	})
+
+	app.Get("/panic", func(c *Context) error {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/panic", nil)
+	app.ServeHTTP(w, req)
+
+	if w.Code != 500 {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if recovered != "boom" {
+		t.Errorf("Expected recovered value 'boom', got %v", recovered)
+	}
+	if stack == "" {
+		t.Error("Expected stack trace to be passed to the hook")
+	}
+	if wroteBeforeHook {
+		t.Error("Expected hook to run before the error response is written")
+	}
+}
+
+func TestGroupUseFor(t *testing.T) {
+	app := New()
+	api := app.Group("/api")
+
+	var ran []string
+	api.UseFor([]string{"POST", "put"}, func(c *Context) error {
+		ran = append(ran, c.Req.Method)
+		return c.Next()
+	})
+
+	noop := func(c *Context) error { return c.Text(200, "ok") }
+	api.Get("/items", noop)
+	api.Post("/items", noop)
+	api.Put("/items", noop)
+
+	for _, method := range []string{"GET", "POST", "PUT"} {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(method, "/api/items", nil))
+		if w.Code != 200 {
+			t.Errorf("Expected 200 for %s, got %d", method, w.Code)
+		}
+	}
+
+	if len(ran) != 2 || ran[0] != "POST" || ran[1] != "PUT" {
+		t.Errorf("Expected middleware to run for POST and PUT only, got %v", ran)
+	}
+}
+
+func TestServeHTTPRecoversWithoutRecoveryMiddleware(t *testing.T) {
+	app := New()
+	app.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var hookCalled bool
+	app.OnPanic(func(c *Context, r any, stack string) {
+		hookCalled = true
+	})
+	app.Get("/panic", func(c *Context) error {
+		panic("boom")
+	})
+	app.Get("/ok", func(c *Context) error {
+		return c.Text(200, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	if w.Code != 500 {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if !hookCalled {
+		t.Error("Expected OnPanic hook to run")
+	}
+
+	// The pooled context must still be usable afterwards
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+	if w.Code != 200 || w.Body.String() != "ok" {
+		t.Errorf("Expected 200 ok after a recovered panic, got %d %q", w.Code, w.Body.String())
+	}
+
+	// http.ErrAbortHandler is left for net/http to handle
+	app.Get("/abort", func(c *Context) error {
+		panic(http.ErrAbortHandler)
+	})
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("Expected ErrAbortHandler to propagate, got %v", r)
+		}
+	}()
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+}
+
+func TestResponseTransformer(t *testing.T) {
+	app := New()
+	app.ResponseTransformer(func(c *Context, payload any) any {
+		if c.StatusCode() >= 400 {
+			return payload
+		}
+		return map[string]any{"data": payload}
+	})
+	app.ResponseTransformer(func(c *Context, payload any) any {
+		if m, ok := payload.(map[string]any); ok {
+			m["served_by"] = "ginji"
+		}
+		return payload
+	})
+
+	type user struct {
+		Name string `json:"name"`
+	}
+	app.Get("/user", func(c *Context) error {
+		return c.JSON(200, user{Name: "ann"})
+	})
+	app.Typed().Get("/typed", func(c *Context, _ EmptyRequest) (user, error) {
+		return user{Name: "bob"}, nil
+	})
+	app.Get("/fail", func(c *Context) error {
+		return c.JSON(404, map[string]any{"error": "nope"})
+	})
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/user", `{"data":{"name":"ann"},"served_by":"ginji"}`},
+		{"/typed", `{"data":{"name":"bob"},"served_by":"ginji"}`},
+		{"/fail", `{"error":"nope","served_by":"ginji"}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if got := strings.TrimSpace(w.Body.String()); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.path, tt.expected, got)
+		}
+	}
+}
+
+func TestSafeGoRecoversPanics(t *testing.T) {
+	app := New()
+	app.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	recovered := make(chan any, 1)
+	var hookCtx *Context
+	app.OnPanic(func(c *Context, r any, stack string) {
+		hookCtx = c
+		recovered <- r
+	})
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/items", nil), app)
+
+	safeGo(c, func() { panic("boom") })
+	if r := <-recovered; r != "boom" {
+		t.Errorf("Expected OnPanic hook to receive boom, got %v", r)
+	}
+	// The hook gets a snapshot, never the context that may be reused
+	if hookCtx == c || hookCtx.Req.URL.Path != "/items" {
+		t.Errorf("Expected a copy of the request context, got %p for %p", hookCtx, c)
+	}
+
+	// Without a request the panic is only logged
+	done := make(chan struct{})
+	safeGo(nil, func() {
+		defer close(done)
+		panic("no request")
+	})
+	<-done
+}
+
+func TestPanicLogIncludesRequest(t *testing.T) {
+	var buf bytes.Buffer
+	app := New()
+	app.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	app.Use(RequestID(), Recovery())
+	app.Get("/users/:id", func(c *Context) error {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected 500, got %d", w.Code)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected one JSON log entry, got %q: %v", buf.String(), err)
+	}
+	expected := map[string]any{
+		"request_id": w.Header().Get("X-Request-ID"),
+		"method":     "GET",
+		"route":      "/users/:id",
+		"path":       "/users/42",
+		"panic":      "boom",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, entry[key])
+		}
+	}
+	if stack, _ := entry["stack"].(string); stack == "" {
+		t.Error("Expected stack in log entry")
+	}
+}
+
+func TestAfterResponseHook(t *testing.T) {
+	app := New()
+	app.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	type result struct {
+		order  string
+		status int
+		size   int
+		body   string
+		user   any
+	}
+	var got result
+	var w *httptest.ResponseRecorder
+	execution := ""
+
+	app.OnResponse(func(c *Context) { execution += "onResponse-" })
+	app.AfterResponse(func(c *Context) { panic("hook failure") })
+	app.AfterResponse(func(c *Context) {
+		execution += "afterResponse"
+		user, _ := c.Get("user")
+		got = result{execution, c.StatusCode(), c.ResponseSize(), w.Body.String(), user}
+	})
+	app.Get("/users/:id", func(c *Context) error {
+		c.Set("user", c.Param("id"))
+		return c.Text(http.StatusCreated, "created")
+	})
+	app.Get("/panic", func(c *Context) error {
+		panic("boom")
+	})
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+	expected := result{"onResponse-afterResponse", http.StatusCreated, len("created"), "created", "42"}
+	if got != expected {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if !w.Flushed {
+		t.Error("Expected response to be flushed before the hook ran")
+	}
+
+	// Hooks see the 500 written by panic recovery
+	execution = ""
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	if got.status != http.StatusInternalServerError {
+		t.Errorf("Expected hook to see status 500, got %d", got.status)
+	}
+
+	// and the redirects of CleanPath, sent before routing
+	app.CleanPath = true
+	got = result{}
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/users//42", nil))
+	if w.Code != http.StatusMovedPermanently || got.status != http.StatusMovedPermanently {
+		t.Errorf("Expected hook to see the 301 redirect, got %d (hook %d)", w.Code, got.status)
+	}
+}
@@ -2,9 +2,14 @@ package ginji
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"reflect"
 	"runtime"
+	"strings"
 )
 
 // HTTPError represents an HTTP error with status code, message, and details.
@@ -13,6 +18,10 @@ type HTTPError struct {
 	Message string `json:"message"`
 	Details any    `json:"details,omitempty"`
 	stack   string // internal stack trace
+
+	// internal is the original error when the HTTPError was created from a
+	// plain error. Its message is hidden from clients in release mode.
+	internal error
 }
 
 // Error implements the error interface.
@@ -23,6 +32,11 @@ func (e *HTTPError) Error() string {
 	return fmt.Sprintf("HTTP %d: %s", e.Code, e.Message)
 }
 
+// Unwrap returns the underlying error, if the HTTPError wraps one.
+func (e *HTTPError) Unwrap() error {
+	return e.internal
+}
+
 // WithDetails adds details to the error.
 func (e *HTTPError) WithDetails(details any) *HTTPError {
 	e.Details = details
@@ -65,6 +79,90 @@ var (
 	ErrServiceUnavailable  = NewHTTPError(http.StatusServiceUnavailable)
 )
 
+// HumanizeBindError converts an error returned by the Bind methods into an
+// HTTPError with a client-friendly message, e.g. for JSON decoding errors
+// "field 'age' expected number, got string at offset 12". Validation errors
+// become 422 Unprocessable Entity and oversized bodies 413; everything else
+// is 400 Bad Request. It returns nil for a nil error.
+//
+//	if err := c.BindJSON(&req); err != nil {
+//		c.AbortWithError(http.StatusBadRequest, ginji.HumanizeBindError(err))
+//		return nil
+//	}
+func HumanizeBindError(err error) *HTTPError {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		httpErr     *HTTPError
+		validation  ValidationErrors
+		fieldErrs   BindFieldErrors
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+		maxBytesErr *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr
+	case errors.As(err, &validation):
+		httpErr = NewHTTPError(http.StatusUnprocessableEntity, "Validation failed")
+	case errors.As(err, &fieldErrs):
+		httpErr = newBindHTTPError("Invalid request parameters", err)
+	case errors.As(err, &syntaxErr):
+		httpErr = NewHTTPError(http.StatusBadRequest,
+			fmt.Sprintf("Malformed JSON at offset %d", syntaxErr.Offset))
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		httpErr = NewHTTPError(http.StatusBadRequest, fmt.Sprintf("field '%s' expected %s, got %s at offset %d",
+			field, jsonKind(typeErr.Type), typeErr.Value, typeErr.Offset))
+	case errors.As(err, &maxBytesErr):
+		httpErr = NewHTTPError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit))
+	case errors.Is(err, ErrJSONTrailingData):
+		httpErr = NewHTTPError(http.StatusBadRequest, "Request body must contain a single JSON value")
+	case errors.Is(err, io.EOF):
+		httpErr = NewHTTPError(http.StatusBadRequest, "Request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		httpErr = NewHTTPError(http.StatusBadRequest, "Request body is incomplete JSON")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		httpErr = NewHTTPError(http.StatusBadRequest,
+			"Unknown field "+strings.TrimPrefix(err.Error(), "json: unknown field "))
+	default:
+		httpErr = NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	httpErr.internal = err
+	return httpErr
+}
+
+// jsonKind names the JSON type that decodes into t.
+func jsonKind(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return jsonKind(t.Elem())
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return t.String()
+	}
+}
+
 // ValidationError represents a validation error with field-level details.
 type ValidationError struct {
 	Field   string `json:"field"`
@@ -74,6 +172,11 @@ type ValidationError struct {
 }
 
 // ValidationErrors is a collection of validation errors.
+//
+// Errors are ordered deterministically: struct fields in declaration order,
+// depth-first, so a field's own rule failures come before those of its nested
+// fields. Within a field, rules are reported in tag order. Slice and array
+// elements are visited by index and map entries by sorted key.
 type ValidationErrors []ValidationError
 
 // Error implements the error interface.
@@ -108,7 +211,22 @@ func DefaultErrorHandler() Middleware {
 // handleError handles the error and sends an appropriate response.
 // It uses the custom error handler if set, otherwise uses the default.
 func handleError(c *Context, err error) {
-	// Use custom error handler if set
+	// The response has already started, so writing an error body would corrupt it
+	if c.Written() {
+		c.logger().Warn("Response already written, skipping error response",
+			slog.String("path", c.Req.URL.Path),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	// Use the nearest group's error handler, then the engine's
+	for g := c.group; g != nil; g = g.parent {
+		if g.errorHandler != nil {
+			g.errorHandler(c, err)
+			return
+		}
+	}
 	if c.engine != nil && c.engine.errorHandler != nil {
 		c.engine.errorHandler(c, err)
 		return
@@ -120,7 +238,7 @@ func handleError(c *Context, err error) {
 // defaultErrorHandler is the default error handling logic.
 func defaultErrorHandler(c *Context, err error) {
 	// If already written, don't write again
-	if c.written {
+	if c.Written() {
 		return
 	}
 
@@ -130,13 +248,21 @@ func defaultErrorHandler(c *Context, err error) {
 	// Check if it's an HTTPError
 	if he, ok := err.(*HTTPError); ok {
 		httpErr = he
+		// Keep field-level details of wrapped validation errors
+		if ve, ok := he.internal.(ValidationErrors); ok {
+			validationErrs = ve
+		}
 	} else if ve, ok := err.(ValidationErrors); ok {
 		// Validation error
 		validationErrs = ve
 		httpErr = NewHTTPError(http.StatusUnprocessableEntity, "Validation failed")
+	} else if be, ok := err.(BindFieldErrors); ok {
+		// Binding conversion error
+		httpErr = NewHTTPError(http.StatusBadRequest, "Invalid request parameters").WithDetails(be.Fields())
 	} else {
 		// Generic error - treat as 500
 		httpErr = NewHTTPError(http.StatusInternalServerError, err.Error())
+		httpErr.internal = err
 	}
 
 	// Build error response
@@ -151,6 +277,18 @@ func defaultErrorHandler(c *Context, err error) {
 		response.Errors = validationErrs
 	}
 
+	// Internal error messages may leak implementation details, so in release
+	// mode they are only logged and clients get the generic status text
+	if mode == ReleaseMode && httpErr.internal != nil && httpErr.Code >= http.StatusInternalServerError {
+		c.logger().Error("Internal server error",
+			slog.String("path", c.Req.URL.Path),
+			slog.Int("status", httpErr.Code),
+			slog.String("error", httpErr.internal.Error()),
+		)
+		response.Error = http.StatusText(httpErr.Code)
+		response.Details = nil
+	}
+
 	// Only add stack trace in debug mode, never in production
 	if mode == DebugMode && httpErr.stack != "" {
 		response.Stack = httpErr.stack
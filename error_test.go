@@ -1,10 +1,13 @@
 package ginji
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -127,6 +130,50 @@ func TestContextAbortWithStatusJSON(t *testing.T) {
 	}
 }
 
+func TestAbortWithStatusJSONWritesStatusOnce(t *testing.T) {
+	app := New()
+	app.Get("/", func(c *Context) error {
+		c.AbortWithStatusJSON(http.StatusForbidden, H{"message": "forbidden"})
+		return nil
+	})
+	app.Get("/twice", func(c *Context) error {
+		c.Status(http.StatusAccepted)
+		c.Status(http.StatusInternalServerError)
+		return nil
+	})
+
+	var serverLog bytes.Buffer
+	server := httptest.NewUnstartedServer(app)
+	server.Config.ErrorLog = log.New(&serverLog, "", 0)
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Expected JSON content type, got %q", ct)
+	}
+
+	resp, err = http.Get(server.URL + "/twice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected first status to win, got %d", resp.StatusCode)
+	}
+
+	if strings.Contains(serverLog.String(), "superfluous") {
+		t.Errorf("Expected no superfluous WriteHeader warning, got %q", serverLog.String())
+	}
+}
+
 func TestContextTypedGetters(t *testing.T) {
 	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), nil)
 
@@ -235,3 +282,190 @@ func TestFormatValidationError(t *testing.T) {
 		t.Errorf("Expected tag 'email', got '%s'", ve.Tag)
 	}
 }
+
+func TestAbortWithErrorAfterPartialWrite(t *testing.T) {
+	app := New()
+	customCalled := false
+	app.SetErrorHandler(func(c *Context, err error) {
+		customCalled = true
+		_ = c.JSON(http.StatusInternalServerError, H{"error": err.Error()})
+	})
+	app.Get("/partial", func(c *Context) error {
+		_ = c.Text(http.StatusOK, "partial body")
+		c.AbortWithError(http.StatusInternalServerError, errors.New("late failure"))
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/partial", nil)
+	app.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected original status 200, got %d", w.Code)
+	}
+	if w.Body.String() != "partial body" {
+		t.Errorf("Expected body to be untouched, got '%s'", w.Body.String())
+	}
+	if customCalled {
+		t.Error("Expected error handler to be skipped once the response started")
+	}
+}
+
+func TestContextWritten(t *testing.T) {
+	c, _ := NewTestContextWithRecorder("GET", "/")
+	if c.Written() {
+		t.Error("Expected fresh context to not be written")
+	}
+
+	_ = c.JSON(http.StatusOK, H{"ok": true})
+	if !c.Written() {
+		t.Error("Expected context to be written after JSON")
+	}
+}
+
+func TestInternalErrorHiddenInReleaseMode(t *testing.T) {
+	originalMode := GetMode()
+	defer SetMode(originalMode)
+
+	newApp := func() *Engine {
+		app := New()
+		app.Get("/internal", func(c *Context) error {
+			c.AbortWithError(StatusInternalServerError, errors.New("pq: connection refused"))
+			return nil
+		})
+		app.Get("/explicit", func(c *Context) error {
+			c.AbortWithError(StatusServiceUnavailable, NewHTTPError(StatusServiceUnavailable, "Maintenance"))
+			return nil
+		})
+		return app
+	}
+
+	SetMode(ReleaseMode)
+	app := newApp()
+	w := PerformRequest(app, "GET", "/internal", nil)
+	if w.Code != StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "connection refused") {
+		t.Errorf("Expected internal error to be hidden in release mode, got %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Internal Server Error") {
+		t.Errorf("Expected generic message, got %s", w.Body.String())
+	}
+
+	// Messages from explicit HTTPErrors are intended for clients
+	w = PerformRequest(app, "GET", "/explicit", nil)
+	if !strings.Contains(w.Body.String(), "Maintenance") {
+		t.Errorf("Expected explicit HTTPError message, got %s", w.Body.String())
+	}
+
+	SetMode(TestMode)
+	app = newApp()
+	w = PerformRequest(app, "GET", "/internal", nil)
+	if !strings.Contains(w.Body.String(), "connection refused") {
+		t.Errorf("Expected internal error details outside release mode, got %s", w.Body.String())
+	}
+}
+
+func TestGroupErrorHandler(t *testing.T) {
+	app := New()
+	app.SetErrorHandler(func(c *Context, err error) {
+		_ = c.Text(http.StatusTeapot, "engine")
+	})
+
+	api := app.Group("/api")
+	api.SetErrorHandler(func(c *Context, err error) {
+		_ = c.JSON(http.StatusBadRequest, H{"error": err.Error()})
+	})
+	admin := api.Group("/admin")
+
+	web := app.Group("/web")
+	web.SetErrorHandler(func(c *Context, err error) {
+		_ = c.HTML(http.StatusBadRequest, "<h1>Something went wrong</h1>")
+	})
+
+	fail := func(c *Context) error {
+		c.AbortWithError(http.StatusBadRequest, errors.New("bad input"))
+		return nil
+	}
+	api.Get("/users", fail)
+	admin.Get("/stats", fail)
+	web.Get("/page", fail)
+	app.Get("/root", fail)
+
+	tests := []struct {
+		path        string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"/api/users", http.StatusBadRequest, "application/json", "bad input"},
+		{"/api/admin/stats", http.StatusBadRequest, "application/json", "bad input"},
+		{"/web/page", http.StatusBadRequest, "text/html", "<h1>"},
+		{"/root", http.StatusTeapot, "text/plain", "engine"},
+	}
+
+	for _, tt := range tests {
+		w := PerformRequest(app, "GET", tt.path, nil)
+		if w.Code != tt.code {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.code, w.Code)
+		}
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) {
+			t.Errorf("%s: expected content type %s, got %s", tt.path, tt.contentType, w.Header().Get("Content-Type"))
+		}
+		if !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: expected body to contain %q, got %s", tt.path, tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestHumanizeBindError(t *testing.T) {
+	type Person struct {
+		Name string `json:"name" validate:"required"`
+		Age  int    `json:"age"`
+	}
+
+	bind := func(body string) error {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		c := NewContext(httptest.NewRecorder(), req, New())
+		var p Person
+		return c.BindJSON(&p)
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		code    int
+		message string
+	}{
+		{"type mismatch", `{"name":"ann","age":"ten"}`, 400, "field 'age' expected number, got string at offset 25"},
+		{"syntax error", `{"name":}`, 400, "Malformed JSON at offset 9"},
+		{"empty body", ``, 400, "Request body is empty"},
+		{"truncated", `{"name":"ann"`, 400, "Request body is incomplete JSON"},
+		{"validation", `{"age":3}`, 422, "Validation failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpErr := HumanizeBindError(bind(tt.body))
+			if httpErr == nil {
+				t.Fatal("Expected an error")
+			}
+			if httpErr.Code != tt.code || httpErr.Message != tt.message {
+				t.Errorf("Expected %d %q, got %d %q", tt.code, tt.message, httpErr.Code, httpErr.Message)
+			}
+		})
+	}
+
+	if HumanizeBindError(nil) != nil {
+		t.Error("Expected nil for nil error")
+	}
+	if err := HumanizeBindError(&http.MaxBytesError{Limit: 10}); err.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for oversized body, got %d", err.Code)
+	}
+	existing := NewHTTPError(http.StatusConflict)
+	if HumanizeBindError(existing) != existing {
+		t.Error("Expected HTTPError to be returned unchanged")
+	}
+}
@@ -2,12 +2,21 @@ package ginji
 
 import (
 	"context"
+	"fmt"
+	"io/fs"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"path"
 	"reflect"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -37,27 +46,83 @@ type Engine struct {
 	groups       []*RouterGroup // store all groups
 	hooks        LifecycleHooks
 	plugins      *PluginRegistry
-	container    *Container   // DI container
-	pool         sync.Pool    // context pool
-	Logger       *slog.Logger // structured logger
-	errorHandler ErrorHandler // custom error handler
+	container    *Container         // DI container
+	pool         sync.Pool          // context pool
+	Logger       *slog.Logger       // structured logger
+	errorHandler ErrorHandler       // custom error handler
+	validators   *validatorRegistry // engine-scoped custom validators
+	inFlight     atomic.Int64       // requests currently being served
+	noRoute      Handler            // handler for requests no route matches
+	pre          []Middleware       // middleware run before routing
+
+	bindPrecedence []BindSource   // Bind source order, highest first; nil for the default
+	trustedProxies []netip.Prefix // proxies whose forwarding headers ClientIP believes
+
+	// MaxMultipartMemory is the maximum number of bytes of a multipart form
+	// kept in memory when parsing; larger parts are stored in temporary files.
+	// Default: 32MB
+	MaxMultipartMemory int64
+
+	// MaxDecompressedSize is the maximum number of bytes a compressed
+	// request body may expand to when the Decompress middleware decodes
+	// it, so a small gzip payload cannot exhaust memory. Reading past it
+	// fails with an *http.MaxBytesError, which HumanizeBindError reports
+	// as 413 Request Entity Too Large.
+	// DecompressConfig.MaxSize overrides it per middleware.
+	// Default: 10MB
+	MaxDecompressedSize int64
+
+	// DisallowUnknownFields rejects JSON request bodies containing fields
+	// that match no field of the bind target, instead of silently dropping
+	// them. Use Context.BindJSONStrict to opt in per handler instead.
+	// Default: false
+	DisallowUnknownFields bool
+
+	// MaxHeaderBytes limits the size of the request line and headers the
+	// server reads, as http.Server.MaxHeaderBytes does, for servers started
+	// with Run, Listen and their TLS and shutdown variants.
+	// Default: 0, the net/http default of 1MB
+	MaxHeaderBytes int
+
+	// MaxPathLength and MaxQueryParams reject requests whose path is longer
+	// or whose query has more parameters with 431 Request Header Fields Too
+	// Large before any routing or parsing work. Requests within
+	// MaxHeaderBytes can still be costly to route or bind.
+	// Default: 0, no limit
+	MaxPathLength  int
+	MaxQueryParams int
+
+	// CleanPath normalizes request paths before routing by collapsing
+	// repeated slashes and resolving "." and ".." segments. GET and HEAD
+	// requests are redirected to the cleaned path with 301 Moved
+	// Permanently; other methods are routed as if the cleaned path had been
+	// requested, so their bodies are not lost.
+	// Default: false
+	CleanPath bool
 }
 
 // RouterGroup defines a group of routes.
 type RouterGroup struct {
-	prefix      string
-	middlewares []Middleware
-	parent      *RouterGroup
-	engine      *Engine
+	prefix       string
+	middlewares  []Middleware
+	parent       *RouterGroup
+	engine       *Engine
+	tags         []string              // default OpenAPI tags for routes in the group
+	security     []map[string][]string // default OpenAPI security requirements
+	errorHandler ErrorHandler          // error handler for routes in the group
 }
 
 // New creates a new Engine instance.
 func New() *Engine {
 	engine := &Engine{
-		router:    newRouter(),
-		hooks:     LifecycleHooks{},
-		plugins:   newPluginRegistry(),
-		container: NewContainer(),
+		router:     newRouter(),
+		hooks:      LifecycleHooks{},
+		plugins:    newPluginRegistry(),
+		container:  NewContainer(),
+		validators: newValidatorRegistry(),
+
+		MaxMultipartMemory:  defaultMaxMultipartMemory,
+		MaxDecompressedSize: defaultMaxDecompressedSize,
 	}
 
 	// Initialize logger with appropriate handler based on mode
@@ -97,13 +162,87 @@ func (group *RouterGroup) Group(prefix string) *RouterGroup {
 
 // Use adds middleware to the group.
 func (group *RouterGroup) Use(middlewares ...Middleware) {
-	group.middlewares = append(group.middlewares, middlewares...)
+	where := "Use"
+	if group.prefix != "" {
+		where = fmt.Sprintf("Use of group %q", group.prefix)
+	}
+	group.middlewares = append(group.middlewares, group.engine.nonNilMiddlewares(where, middlewares)...)
+}
+
+// UseFor adds middleware to the group that only runs for the given HTTP
+// methods, e.g. a body size limit applied to POST, PUT and PATCH.
+func (group *RouterGroup) UseFor(methods []string, middlewares ...Middleware) {
+	// Drop nil entries before wrapping hides them from Use
+	middlewares = group.engine.nonNilMiddlewares(fmt.Sprintf("UseFor %v", methods), middlewares)
+	conditions := make([]ConditionFunc, len(methods))
+	for i, method := range methods {
+		conditions[i] = MethodIs(strings.ToUpper(method))
+	}
+	for _, mw := range middlewares {
+		group.Use(Only(Or(conditions...), mw))
+	}
+}
+
+// Tags sets default OpenAPI tags for routes registered in the group and
+// its subgroups. Tags set on a route are merged with the group's tags.
+func (group *RouterGroup) Tags(tags ...string) *RouterGroup {
+	group.tags = append(group.tags, tags...)
+	return group
+}
+
+// Security adds a default OpenAPI security requirement for routes
+// registered in the group and its subgroups.
+func (group *RouterGroup) Security(scheme string, scopes ...string) *RouterGroup {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	group.security = append(group.security, map[string][]string{scheme: scopes})
+	return group
+}
+
+// newRouteMetadata creates route metadata pre-populated with the tags and
+// security requirements inherited from the group and its parents.
+func (group *RouterGroup) newRouteMetadata() *RouteMetadata {
+	meta := &RouteMetadata{
+		Responses: make(map[string]reflect.Type),
+	}
+
+	var chain []*RouterGroup
+	for g := group; g != nil; g = g.parent {
+		chain = append(chain, g)
+	}
+	// Apply from the outermost group inwards
+	for i := len(chain) - 1; i >= 0; i-- {
+		meta.Tags = mergeTags(meta.Tags, chain[i].tags)
+		meta.Security = append(meta.Security, chain[i].security...)
+	}
+	meta.groupTags = meta.Tags
+	return meta
+}
+
+// mergeTags appends tags to base, skipping duplicates.
+func mergeTags(base []string, tags []string) []string {
+	merged := append([]string(nil), base...)
+	for _, tag := range tags {
+		if !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
 }
 
 // addRoute registers a route with the router.
 func (group *RouterGroup) addRoute(method string, comp string, handler Handler) {
 	pattern := group.prefix + comp
 	group.engine.router.addRoute(method, pattern, handler)
+	group.engine.router.setRouteGroup(method+"-"+pattern, group)
+}
+
+// SetErrorHandler sets the error handler for routes registered in the group
+// and its subgroups. Errors are handled by the nearest group's handler,
+// falling back to the engine's error handler.
+func (group *RouterGroup) SetErrorHandler(handler ErrorHandler) {
+	group.errorHandler = handler
 }
 
 // Get registers a GET request handler.
@@ -111,12 +250,11 @@ func (group *RouterGroup) Get(pattern string, handler Handler) *Route {
 	fullPattern := group.prefix + pattern
 	route := &Route{
 		engine:  group.engine,
+		group:   group,
 		method:  "GET",
 		pattern: fullPattern,
 		handler: handler,
-		meta: &RouteMetadata{
-			Responses: make(map[string]reflect.Type),
-		},
+		meta:    group.newRouteMetadata(),
 	}
 	route.build()
 	return route
@@ -127,12 +265,11 @@ func (group *RouterGroup) Post(pattern string, handler Handler) *Route {
 	fullPattern := group.prefix + pattern
 	route := &Route{
 		engine:  group.engine,
+		group:   group,
 		method:  "POST",
 		pattern: fullPattern,
 		handler: handler,
-		meta: &RouteMetadata{
-			Responses: make(map[string]reflect.Type),
-		},
+		meta:    group.newRouteMetadata(),
 	}
 	route.build()
 	return route
@@ -143,12 +280,11 @@ func (group *RouterGroup) Put(pattern string, handler Handler) *Route {
 	fullPattern := group.prefix + pattern
 	route := &Route{
 		engine:  group.engine,
+		group:   group,
 		method:  "PUT",
 		pattern: fullPattern,
 		handler: handler,
-		meta: &RouteMetadata{
-			Responses: make(map[string]reflect.Type),
-		},
+		meta:    group.newRouteMetadata(),
 	}
 	route.build()
 	return route
@@ -159,12 +295,11 @@ func (group *RouterGroup) Delete(pattern string, handler Handler) *Route {
 	fullPattern := group.prefix + pattern
 	route := &Route{
 		engine:  group.engine,
+		group:   group,
 		method:  "DELETE",
 		pattern: fullPattern,
 		handler: handler,
-		meta: &RouteMetadata{
-			Responses: make(map[string]reflect.Type),
-		},
+		meta:    group.newRouteMetadata(),
 	}
 	route.build()
 	return route
@@ -175,18 +310,18 @@ func (group *RouterGroup) Patch(pattern string, handler Handler) *Route {
 	fullPattern := group.prefix + pattern
 	route := &Route{
 		engine:  group.engine,
+		group:   group,
 		method:  "PATCH",
 		pattern: fullPattern,
 		handler: handler,
-		meta: &RouteMetadata{
-			Responses: make(map[string]reflect.Type),
-		},
+		meta:    group.newRouteMetadata(),
 	}
 	route.build()
 	return route
 }
 
-// Static registers a route to serve static files.
+// Static registers a route to serve static files. Like http.FileServer it
+// lists directories without an index.html; use ServeFiles to disable that.
 func (group *RouterGroup) Static(prefix, root string) {
 	fs := http.StripPrefix(group.prefix+prefix, http.FileServer(http.Dir(root)))
 	handler := func(c *Context) error {
@@ -201,45 +336,79 @@ func (group *RouterGroup) Static(prefix, root string) {
 	group.addRoute("GET", pattern, handler)
 }
 
+// StaticFS registers a route to serve files from an fs.FS, such as an embed.FS.
+// This allows assets to be bundled into the binary for single-file deploys.
+func (group *RouterGroup) StaticFS(prefix string, fsys fs.FS) {
+	fileServer := http.StripPrefix(group.prefix+prefix, http.FileServerFS(fsys))
+	handler := func(c *Context) error {
+		fileServer.ServeHTTP(c.Res, c.Req)
+		return nil
+	}
+	pattern := prefix + "/*filepath"
+	group.addRoute("GET", pattern, handler)
+}
+
 // Typed creates a typed route builder for this router group.
 // This avoids the limitation of Go not allowing generic methods.
 func (group *RouterGroup) Typed() *TypedRouteBuilder {
-	return &TypedRouteBuilder{group: group}
+	return &TypedRouteBuilder{group: group, postStatus: StatusCreated}
 }
 
 // TypedRouteBuilder provides type-safe route registration.
 type TypedRouteBuilder struct {
-	group *RouterGroup
+	group      *RouterGroup
+	postStatus int // success status for POST handlers with a response body
+}
+
+// PostStatus sets the success status used by Post for handlers that return
+// a response body. It defaults to 201 Created; use StatusOK to opt out.
+func (t *TypedRouteBuilder) PostStatus(code int) *TypedRouteBuilder {
+	t.postStatus = code
+	return t
 }
 
 // Get registers a type-safe GET request handler.
 func (t *TypedRouteBuilder) Get(pattern string, handler any) *Route {
-	return t.group.Get(pattern, wrapTypedHandler(handler))
+	return t.describe(t.group.Get(pattern, wrapTypedHandler(handler, StatusOK)), handler)
 }
 
 // Post registers a type-safe POST request handler.
+// Responses are sent with 201 Created by default, see PostStatus.
 func (t *TypedRouteBuilder) Post(pattern string, handler any) *Route {
-	return t.group.Post(pattern, wrapTypedHandler(handler))
+	return t.describe(t.group.Post(pattern, wrapTypedHandler(handler, t.postStatus)), handler)
 }
 
 // Put registers a type-safe PUT request handler.
 func (t *TypedRouteBuilder) Put(pattern string, handler any) *Route {
-	return t.group.Put(pattern, wrapTypedHandler(handler))
+	return t.describe(t.group.Put(pattern, wrapTypedHandler(handler, StatusOK)), handler)
 }
 
 // Delete registers a type-safe DELETE request handler.
 func (t *TypedRouteBuilder) Delete(pattern string, handler any) *Route {
-	return t.group.Delete(pattern, wrapTypedHandler(handler))
+	return t.describe(t.group.Delete(pattern, wrapTypedHandler(handler, StatusOK)), handler)
 }
 
 // Patch registers a type-safe PATCH request handler.
 func (t *TypedRouteBuilder) Patch(pattern string, handler any) *Route {
-	return t.group.Patch(pattern, wrapTypedHandler(handler))
+	return t.describe(t.group.Patch(pattern, wrapTypedHandler(handler, StatusOK)), handler)
+}
+
+// describe records the handler's request type on the route so its
+// path, query and header fields are documented as OpenAPI parameters.
+func (t *TypedRouteBuilder) describe(route *Route, handler any) *Route {
+	handlerType := reflect.TypeOf(handler)
+	if handlerType.Kind() == reflect.Func && handlerType.NumIn() == 2 {
+		if reqType := handlerType.In(1); reqType != reflect.TypeOf(EmptyRequest{}) {
+			route.meta.ParamsType = reqType
+		}
+	}
+	return route
 }
 
 // wrapTypedHandler wraps any typed handler into a regular Handler.
 // This uses reflection to detect and wrap the handler appropriately.
-func wrapTypedHandler(handler any) Handler {
+// successStatus is used when the handler returns a plain response body.
+func wrapTypedHandler(handler any, successStatus int) Handler {
 	handlerVal := reflect.ValueOf(handler)
 	handlerType := handlerVal.Type()
 
@@ -269,12 +438,12 @@ func wrapTypedHandler(handler any) Handler {
 		if !isEmptyReq {
 			reqPtr := reflect.New(reqType)
 			if err := bindTypedRequest(c, reqPtr.Interface()); err != nil {
-				c.AbortWithError(StatusBadRequest, NewHTTPError(StatusBadRequest, "Invalid request: "+err.Error()))
+				c.AbortWithError(StatusBadRequest, newBindHTTPError("Invalid request: "+err.Error(), err))
 				return nil
 			}
 
-			if err := validateStruct(reqPtr.Elem().Interface()); err != nil {
-				c.AbortWithError(StatusBadRequest, err)
+			if err := c.validate(reqPtr.Elem().Interface()); err != nil {
+				c.AbortWithError(StatusUnprocessableEntity, err)
 				return nil
 			}
 
@@ -302,7 +471,13 @@ func wrapTypedHandler(handler any) Handler {
 		isEmptyRes := resType == reflect.TypeOf(EmptyRequest{})
 		if !isEmptyRes {
 			res := results[0].Interface()
-			_ = c.JSON(StatusOK, res)
+			if responder, ok := asResponder(res); ok {
+				if err := responder.Respond(c); err != nil {
+					c.AbortWithError(StatusInternalServerError, err)
+				}
+				return nil
+			}
+			_ = c.JSON(successStatus, res)
 		} else {
 			if c.StatusCode() == StatusOK {
 				c.Status(StatusNoContent)
@@ -313,9 +488,74 @@ func wrapTypedHandler(handler any) Handler {
 	}
 }
 
+// NoRoute sets the handler for requests that match no route, replacing
+// the default 404 response. Engine and group middleware still run first.
+// Combined with StaticFS it can serve a single-page app shell for
+// client-side routes:
+//
+//	app.StaticFS("/assets", assets)
+//	app.NoRoute(func(c *ginji.Context) error {
+//		if c.Req.Method != http.MethodGet {
+//			return c.Text(http.StatusNotFound, "404 NOT FOUND")
+//		}
+//		http.ServeFileFS(c.Res, c.Req, dist, "index.html")
+//		return nil
+//	})
+func (engine *Engine) NoRoute(handler Handler) {
+	engine.noRoute = handler
+}
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Method string
+	Path   string
+}
+
+// Routes returns the registered routes sorted by path and method.
+func (engine *Engine) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(engine.router.handlers))
+	for key := range engine.router.handlers {
+		method, path, _ := strings.Cut(key, "-")
+		routes = append(routes, RouteInfo{Method: method, Path: path})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// logStartup logs the listen address, mode and route table in debug mode.
+func (engine *Engine) logStartup(addr string) {
+	if mode != DebugMode || engine.Logger == nil {
+		return
+	}
+	routes := engine.Routes()
+	engine.Logger.Info("Starting in debug mode",
+		slog.String("addr", addr),
+		slog.String("mode", string(mode)),
+		slog.Int("routes", len(routes)),
+	)
+	for _, route := range routes {
+		engine.Logger.Debug("Route", slog.String("method", route.Method), slog.String("path", route.Path))
+	}
+}
+
 // Run starts the HTTP server (alias for Listen).
 func (engine *Engine) Run(addr string) error {
-	return http.ListenAndServe(addr, engine)
+	engine.logStartup(addr)
+	return engine.newServer(addr).ListenAndServe()
+}
+
+// newServer returns an http.Server for addr configured from the engine.
+func (engine *Engine) newServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:           addr,
+		Handler:        engine,
+		MaxHeaderBytes: engine.MaxHeaderBytes,
+	}
 }
 
 // Listen starts the HTTP server.
@@ -325,17 +565,15 @@ func (engine *Engine) Listen(addr string) error {
 
 // ListenTLS starts the HTTPS server.
 func (engine *Engine) ListenTLS(addr, certFile, keyFile string) error {
-	return http.ListenAndServeTLS(addr, certFile, keyFile, engine)
+	engine.logStartup(addr)
+	return engine.newServer(addr).ListenAndServeTLS(certFile, keyFile)
 }
 
 // ListenWithShutdown starts the HTTP server with graceful shutdown support.
 // It listens for SIGINT/SIGTERM signals and gracefully shuts down the server
 // with the specified timeout.
 func (engine *Engine) ListenWithShutdown(addr string, timeout time.Duration) error {
-	srv := &http.Server{
-		Addr:    addr,
-		Handler: engine,
-	}
+	srv := engine.newServer(addr)
 
 	// Channel to listen for errors from the server
 	serverErrors := make(chan error, 1)
@@ -343,6 +581,7 @@ func (engine *Engine) ListenWithShutdown(addr string, timeout time.Duration) err
 	// Start server in a goroutine
 	go func() {
 		engine.Logger.Info("Server starting", slog.String("addr", addr))
+		engine.logStartup(addr)
 		serverErrors <- srv.ListenAndServe()
 	}()
 
@@ -367,6 +606,12 @@ func (engine *Engine) ListenWithShutdown(addr string, timeout time.Duration) err
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 
+		// Report in-flight requests while they drain
+		engine.Logger.Info("Draining in-flight requests", slog.Int("in_flight", engine.InFlight()))
+		drained := make(chan struct{})
+		defer close(drained)
+		go engine.logDraining(drained, time.Second)
+
 		// Attempt graceful shutdown
 		if err := srv.Shutdown(ctx); err != nil {
 			// Force close after timeout
@@ -384,10 +629,7 @@ func (engine *Engine) ListenWithShutdown(addr string, timeout time.Duration) err
 
 // ListenTLSWithShutdown starts the HTTPS server with graceful shutdown support.
 func (engine *Engine) ListenTLSWithShutdown(addr, certFile, keyFile string, timeout time.Duration) error {
-	srv := &http.Server{
-		Addr:    addr,
-		Handler: engine,
-	}
+	srv := engine.newServer(addr)
 
 	// Channel to listen for errors from the server
 	serverErrors := make(chan error, 1)
@@ -395,6 +637,7 @@ func (engine *Engine) ListenTLSWithShutdown(addr, certFile, keyFile string, time
 	// Start server in a goroutine
 	go func() {
 		engine.Logger.Info("HTTPS server starting", slog.String("addr", addr))
+		engine.logStartup(addr)
 		serverErrors <- srv.ListenAndServeTLS(certFile, keyFile)
 	}()
 
@@ -419,6 +662,12 @@ func (engine *Engine) ListenTLSWithShutdown(addr, certFile, keyFile string, time
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 
+		// Report in-flight requests while they drain
+		engine.Logger.Info("Draining in-flight requests", slog.Int("in_flight", engine.InFlight()))
+		drained := make(chan struct{})
+		defer close(drained)
+		go engine.logDraining(drained, time.Second)
+
 		// Attempt graceful shutdown
 		if err := srv.Shutdown(ctx); err != nil {
 			// Force close after timeout
@@ -436,8 +685,39 @@ func (engine *Engine) ListenTLSWithShutdown(addr, certFile, keyFile string, time
 
 // ServeHTTP makes the router implement the http.Handler interface.
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	engine.inFlight.Add(1)
+	defer engine.inFlight.Add(-1)
+
+	if engine.exceedsRequestLimits(req) {
+		c := engine.pool.Get().(*Context)
+		c.Reset(w, req, engine)
+		defer engine.release(c)
+		c.AbortWithError(StatusRequestHeaderFieldsTooLarge, NewHTTPError(StatusRequestHeaderFieldsTooLarge))
+		return
+	}
+
+	if engine.CleanPath {
+		if cleaned := cleanPath(req.URL.Path); cleaned != req.URL.Path {
+			if req.Method == http.MethodGet || req.Method == http.MethodHead {
+				// Redirect through a pooled Context so AfterResponse hooks
+				// see this response too
+				c := engine.pool.Get().(*Context)
+				c.Reset(w, req, engine)
+				defer engine.release(c)
+				target := *req.URL
+				target.Path = cleaned
+				target.RawPath = ""
+				http.Redirect(c.Res, req, target.RequestURI(), http.StatusMovedPermanently)
+				return
+			}
+			req.URL.Path = cleaned
+			req.URL.RawPath = ""
+		}
+	}
+
 	c := engine.pool.Get().(*Context)
 	c.Reset(w, req, engine)
+	defer engine.release(c)
 
 	// Add system middleware to handle OnResponse hooks
 	// This must be the first handler in the chain to ensure it runs last on the way back
@@ -447,10 +727,45 @@ func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return err
 	})
 
+	// Pre-routing middleware run first and may rewrite the request, so
+	// routing is deferred until they call Next
+	if len(engine.pre) == 0 {
+		engine.dispatch(c)
+	} else {
+		for _, mw := range engine.pre {
+			c.handlers = append(c.handlers, Handler(mw))
+		}
+		c.handlers = append(c.handlers, func(c *Context) error {
+			engine.dispatch(c)
+			return nil
+		})
+	}
+
+	// Execute the chain
+	_ = c.Next()
+}
+
+// exceedsRequestLimits reports whether req breaks MaxPathLength or
+// MaxQueryParams. Query parameters are counted by their separators, without
+// parsing the query.
+func (engine *Engine) exceedsRequestLimits(req *http.Request) bool {
+	if engine.MaxPathLength > 0 && len(req.URL.Path) > engine.MaxPathLength {
+		return true
+	}
+	if engine.MaxQueryParams > 0 && req.URL.RawQuery != "" {
+		return strings.Count(req.URL.RawQuery, "&")+1 > engine.MaxQueryParams
+	}
+	return false
+}
+
+// dispatch appends the matching group middleware and route handlers to
+// the chain of c.
+func (engine *Engine) dispatch(c *Context) {
 	// Collect all middleware
 	// Note: In a real high-perf scenario, we should pre-calculate this or optimize it
+	path := c.Req.URL.Path
 	for _, group := range engine.groups {
-		if len(group.prefix) == 0 || (len(req.URL.Path) >= len(group.prefix) && req.URL.Path[:len(group.prefix)] == group.prefix) {
+		if len(group.prefix) == 0 || (len(path) >= len(group.prefix) && path[:len(group.prefix)] == group.prefix) {
 			for _, mw := range group.middlewares {
 				c.handlers = append(c.handlers, Handler(mw))
 			}
@@ -459,12 +774,160 @@ func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	// Dispatch to router to find route handlers
 	engine.router.handle(c, engine)
+}
 
-	// Execute the chain
-	_ = c.Next()
+// SetTrustedProxies sets the IP addresses or CIDR ranges of the reverse
+// proxies in front of the app. Context.ClientIP only believes the
+// X-Forwarded-For and X-Real-IP headers of requests from these proxies,
+// since any client can send them. No proxies are trusted by default.
+func (engine *Engine) SetTrustedProxies(proxies ...string) error {
+	prefixes, err := parsePrefixes(proxies)
+	if err != nil {
+		return err
+	}
+	engine.trustedProxies = prefixes
+	return nil
+}
 
-	// Return to pool
-	engine.pool.Put(c)
+// isTrustedProxy reports whether ip belongs to a trusted proxy.
+func (engine *Engine) isTrustedProxy(ip netip.Addr) bool {
+	return prefixesContain(engine.trustedProxies, ip)
+}
+
+// parsePrefixes parses IP addresses and CIDR ranges, treating a bare
+// address as a single-address range.
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("ginji: invalid CIDR %q: %w", value, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("ginji: invalid IP %q: %w", value, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// prefixesContain reports whether any of prefixes contains ip.
+func prefixesContain(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// URL builds the path of the route named name, filling its parameters
+// from params. It fails if no route has the name or a parameter is
+// missing:
+//
+//	app.Get("/users/:id", showUser).Name("users.show")
+//	path, err := app.URL("users.show", map[string]string{"id": "42"}) // "/users/42"
+func (engine *Engine) URL(name string, params map[string]string) (string, error) {
+	return engine.router.buildURL(name, params)
+}
+
+// RouteMiddleware returns the middleware added to the route registered
+// for method and the full pattern with Route.Middlewares. Global and group
+// middleware are not included.
+func (engine *Engine) RouteMiddleware(method, pattern string) []Middleware {
+	return slices.Clone(engine.router.getRouteMiddleware(strings.ToUpper(method) + "-" + pattern))
+}
+
+// OverrideMiddleware replaces the middleware added to a route with
+// Route.Middlewares, e.g. to swap real authentication for a stub in tests:
+//
+//	app.OverrideMiddleware("GET", "/admin/users", []ginji.Middleware{fakeAuth})
+//
+// Global and group middleware are unaffected. It panics if no route is
+// registered for method and the full pattern. Like route registration, it
+// must not be called while the engine is serving requests.
+func (engine *Engine) OverrideMiddleware(method, pattern string, middlewares []Middleware) {
+	key := strings.ToUpper(method) + "-" + pattern
+	if _, ok := engine.router.handlers[key]; !ok {
+		panic(fmt.Sprintf("ginji: no route registered for %s %s", method, pattern))
+	}
+	engine.router.setRouteMiddleware(key, engine.nonNilMiddlewares("OverrideMiddleware", middlewares))
+}
+
+// Pre adds middleware that runs before routing, so it can rewrite the
+// request path or method that routing and group middleware selection see.
+// Pre middleware must call c.Next for the request to be routed.
+func (engine *Engine) Pre(middlewares ...Middleware) {
+	engine.pre = append(engine.pre, engine.nonNilMiddlewares("Pre", middlewares)...)
+}
+
+// release returns c to the pool. As a safety net for routes without the
+// Recovery middleware, it also recovers a panic from the handler chain,
+// logs it and responds with 500. http.ErrAbortHandler is re-panicked so
+// net/http can abort the response as intended. A status set without a
+// body is sent, and AfterResponse hooks run last, once the response is
+// complete but before c is reused.
+func (engine *Engine) release(c *Context) {
+	defer engine.pool.Put(c)
+	defer engine.executeAfterResponse(c)
+
+	r := recover()
+	// Stop goroutines bound to the request before the final write
+	c.runCleanups()
+	if r != nil {
+		if r == http.ErrAbortHandler {
+			panic(r)
+		}
+
+		stack := string(debug.Stack())
+		c.logger().Error("Recovered from panic", append(c.requestAttrs(), slog.Any("panic", r), slog.String("stack", stack))...)
+		engine.executeOnPanic(c, r, stack)
+		if !c.Written() {
+			c.AbortWithError(StatusInternalServerError, fmt.Errorf("panic: %v", r))
+		}
+	}
+
+	// Send a status set without a body, e.g. c.Status(http.StatusNoContent)
+	c.writer.finish()
+}
+
+// cleanPath returns the canonical form of p: repeated slashes collapsed
+// and "." and ".." segments resolved. A trailing slash is kept.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// InFlight returns the number of requests currently being served.
+func (engine *Engine) InFlight() int {
+	return int(engine.inFlight.Load())
+}
+
+// logDraining periodically logs the in-flight request count until done is closed.
+func (engine *Engine) logDraining(done <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			engine.Logger.Info("Draining in-flight requests", slog.Int("in_flight", engine.InFlight()))
+		}
+	}
 }
 
 // SetMode sets the application mode (debug, release, test).
@@ -507,6 +970,24 @@ func (e *Engine) Container() *Container {
 	return e.container
 }
 
+// DebugServices logs every service registered in the DI container.
+// It is useful as a startup sanity check to catch missing or misnamed services.
+func (e *Engine) DebugServices() {
+	services := e.container.List()
+	e.Logger.Info("Registered services", slog.Int("count", len(services)))
+	for _, service := range services {
+		typeName := "<nil>"
+		if service.Type != nil {
+			typeName = service.Type.String()
+		}
+		e.Logger.Info("Service",
+			slog.String("name", service.Name),
+			slog.String("lifetime", service.Lifetime.String()),
+			slog.String("type", typeName),
+		)
+	}
+}
+
 // SetErrorHandler sets a custom error handler for the application.
 // The custom handler will be called instead of the default error handler.
 func (e *Engine) SetErrorHandler(handler ErrorHandler) {
@@ -1,6 +1,10 @@
 package ginji
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -88,6 +92,448 @@ func TestBindAll(t *testing.T) {
 	}
 }
 
+func TestBindPrecedence(t *testing.T) {
+	type Request struct {
+		ID   string `path:"id" query:"id" json:"id"`
+		Name string `query:"name" json:"name" header:"X-Name"`
+	}
+
+	newContext := func(engine *Engine) *Context {
+		req := httptest.NewRequest("PUT", "/users/path-id?id=query-id&name=query-name", strings.NewReader(`{"id":"body-id","name":"body-name"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Name", "header-name")
+		c := NewContext(httptest.NewRecorder(), req, engine)
+		c.Params = map[string]string{"id": "path-id"}
+		return c
+	}
+
+	// Default: path > body > query > header
+	for name, bind := range map[string]func(*Context, any) error{
+		"BindAll": (*Context).BindAll,
+		"Bind":    (*Context).Bind,
+	} {
+		var r Request
+		if err := bind(newContext(New()), &r); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if r.ID != "path-id" || r.Name != "body-name" {
+			t.Errorf("%s: expected path id and body name, got %+v", name, r)
+		}
+	}
+
+	// Custom order: query overrides body, header is not read
+	app := New()
+	app.SetBindPrecedence(BindQuery, BindPath, BindBody)
+	var r Request
+	if err := newContext(app).Bind(&r); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if r.ID != "query-id" || r.Name != "query-name" {
+		t.Errorf("Expected query values to win, got %+v", r)
+	}
+
+	app.SetBindPrecedence(BindHeader)
+	r = Request{}
+	if err := newContext(app).BindAll(&r); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if r.ID != "" || r.Name != "header-name" {
+		t.Errorf("Expected only the header to be bound, got %+v", r)
+	}
+}
+
+func TestBind(t *testing.T) {
+	type Params struct {
+		ID      int    `path:"id" query:"id"`
+		Search  string `query:"q"`
+		Token   string `header:"X-Token"`
+		Session string `cookie:"session"`
+		Name    string `json:"name" query:"name" validate:"required"`
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/users/123?q=test&id=999&name=query", strings.NewReader(`{"name":"John"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Token", "secret")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+
+	c := NewContext(w, req, nil)
+	c.Params = map[string]string{"id": "123"}
+
+	var params Params
+	if err := c.Bind(&params); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if params.ID != 123 {
+		t.Errorf("Expected path to take precedence over query, got ID %d", params.ID)
+	}
+	if params.Name != "John" {
+		t.Errorf("Expected body to take precedence over query, got name %s", params.Name)
+	}
+	if params.Search != "test" {
+		t.Errorf("Expected query 'test', got %s", params.Search)
+	}
+	if params.Token != "secret" {
+		t.Errorf("Expected header 'secret', got %s", params.Token)
+	}
+	if params.Session != "abc" {
+		t.Errorf("Expected cookie 'abc', got %s", params.Session)
+	}
+}
+
+func TestBindSkipsUnreferencedSources(t *testing.T) {
+	type QueryOnly struct {
+		Page int `query:"page" validate:"required"`
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/items?page=2", strings.NewReader(`not json`))
+	req.Header.Set("Content-Type", "application/json")
+
+	c := NewContext(w, req, nil)
+
+	var q QueryOnly
+	if err := c.Bind(&q); err != nil {
+		t.Fatalf("Expected body to be ignored, got %v", err)
+	}
+	if q.Page != 2 {
+		t.Errorf("Expected page 2, got %d", q.Page)
+	}
+
+	// Missing required value still fails validation
+	req = httptest.NewRequest("GET", "/items", nil)
+	c = NewContext(httptest.NewRecorder(), req, nil)
+	if err := c.Bind(&QueryOnly{}); err == nil {
+		t.Error("Expected validation error")
+	}
+}
+
+func TestBindSkipsDashFields(t *testing.T) {
+	type Account struct {
+		Name    string `query:"name" form:"name" header:"X-Name"`
+		IsAdmin bool   `json:"-" query:"-" form:"-" header:"-" path:"-"`
+	}
+
+	// Query, header and path
+	req := httptest.NewRequest("GET", "/accounts/1?name=john&-=true&IsAdmin=true", nil)
+	req.Header.Set("-", "true")
+	c := NewContext(httptest.NewRecorder(), req, nil)
+	c.Params = map[string]string{"-": "true", "isadmin": "true"}
+
+	var account Account
+	if err := c.Bind(&account); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if account.Name != "john" {
+		t.Errorf("Expected name 'john', got %s", account.Name)
+	}
+	if account.IsAdmin {
+		t.Error("Expected IsAdmin to never be bound from request input")
+	}
+
+	// Form
+	req = httptest.NewRequest("POST", "/accounts", strings.NewReader("name=john&-=true&IsAdmin=true"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c = NewContext(httptest.NewRecorder(), req, nil)
+
+	account = Account{}
+	if err := c.Bind(&account); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if account.IsAdmin {
+		t.Error("Expected IsAdmin to never be bound from form input")
+	}
+}
+
+func TestBindJSONFields(t *testing.T) {
+	type User struct {
+		Name    string `json:"name" validate:"required"`
+		Email   string `json:"email"`
+		IsAdmin bool   `json:"is_admin"`
+	}
+
+	body := `{"name":"john","Email":"john@example.com","is_admin":true}`
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	c := NewContext(httptest.NewRecorder(), req, nil)
+
+	user := User{IsAdmin: false}
+	if err := c.BindJSONFields(&user, "name", "email"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.Name != "john" || user.Email != "john@example.com" {
+		t.Errorf("Expected allowed fields to be bound, got %+v", user)
+	}
+	if user.IsAdmin {
+		t.Error("Expected is_admin to be dropped from binding")
+	}
+
+	// Validation still runs on the filtered result
+	req = httptest.NewRequest("POST", "/users", strings.NewReader(body))
+	c = NewContext(httptest.NewRecorder(), req, nil)
+	if err := c.BindJSONFields(&User{}, "email"); err == nil {
+		t.Error("Expected validation error when required field is not allowed")
+	}
+}
+
+func TestParseJSONAndValidate(t *testing.T) {
+	type User struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	// Decoding alone does not validate
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":""}`))
+	c := NewContext(httptest.NewRecorder(), req, nil)
+	var user User
+	if err := c.ParseJSON(&user); err != nil {
+		t.Fatalf("Expected no error from ParseJSON, got %v", err)
+	}
+	var validationErrs ValidationErrors
+	if err := c.Validate(&user); !errors.As(err, &validationErrs) {
+		t.Errorf("Expected ValidationErrors from Validate, got %v", err)
+	}
+
+	// Malformed input is a parse error, not a validation error
+	req = httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":`))
+	c = NewContext(httptest.NewRecorder(), req, nil)
+	err := c.ParseJSON(&user)
+	if err == nil || errors.As(err, &validationErrs) {
+		t.Errorf("Expected decode error from ParseJSON, got %v", err)
+	}
+	if code := HumanizeBindError(err).Code; code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", code)
+	}
+}
+
+func TestBindJSONRejectsTrailingData(t *testing.T) {
+	type payload struct {
+		A int `json:"a"`
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{"single value", `{"a":1}`, false},
+		{"trailing whitespace", "{\"a\":1}\n\t ", false},
+		{"second object", `{"a":1}{"b":2}`, true},
+		{"trailing garbage", `{"a":1} garbage`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			c := NewContext(httptest.NewRecorder(), req, nil)
+			err := c.BindJSON(&payload{})
+			if tt.wantErr != errors.Is(err, ErrJSONTrailingData) {
+				t.Errorf("Expected trailing data error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	app := New()
+	app.Typed().Post("/items", func(c *Context, req payload) (payload, error) {
+		return req, nil
+	})
+	w := PerformRequest(app, "POST", "/items", strings.NewReader(`{"a":1}{"a":2}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for trailing JSON in typed handler, got %d", w.Code)
+	}
+	if got := HumanizeBindError(ErrJSONTrailingData).Message; got != "Request body must contain a single JSON value" {
+		t.Errorf("Unexpected humanized message %q", got)
+	}
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+	const body = `{"name":"jane","nmae":"typo"}`
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	// Lenient by default
+	app := New()
+	c := NewContext(httptest.NewRecorder(), newRequest(), app)
+	if err := c.BindJSON(&payload{}); err != nil {
+		t.Errorf("Expected unknown fields to be ignored by default, got %v", err)
+	}
+
+	// BindJSONStrict opts in per call
+	c = NewContext(httptest.NewRecorder(), newRequest(), app)
+	err := c.BindJSONStrict(&payload{})
+	if err == nil {
+		t.Fatal("Expected BindJSONStrict to reject unknown field")
+	}
+	if msg := HumanizeBindError(err).Message; msg != `Unknown field "nmae"` {
+		t.Errorf("Unexpected humanized message %q", msg)
+	}
+
+	// The engine option applies to every JSON binding
+	app.DisallowUnknownFields = true
+	c = NewContext(httptest.NewRecorder(), newRequest(), app)
+	if err := c.BindJSON(&payload{}); err == nil {
+		t.Error("Expected BindJSON to reject unknown field")
+	}
+	c = NewContext(httptest.NewRecorder(), newRequest(), app)
+	if err := c.Bind(&payload{}); err == nil {
+		t.Error("Expected Bind to reject unknown field")
+	}
+	c = NewContext(httptest.NewRecorder(), newRequest(), app)
+	if err := c.BindJSONFields(&payload{}, "name"); err == nil {
+		t.Error("Expected BindJSONFields to reject unknown field")
+	}
+
+	app.Typed().Post("/items", func(c *Context, req payload) (payload, error) {
+		return req, nil
+	})
+	w := PerformRequest(app, "POST", "/items", strings.NewReader(body))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 from typed handler, got %d", w.Code)
+	}
+}
+
+func TestBindValidateVendorJSON(t *testing.T) {
+	type payload struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"name":"jane"}`))
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+	c := NewContext(httptest.NewRecorder(), req, nil)
+	var p payload
+	if err := c.BindValidate(&p); err != nil || p.Name != "jane" {
+		t.Errorf("Expected vendor JSON to bind, got %+v (%v)", p, err)
+	}
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader("name=john"))
+	req.Header.Set("Content-Type", "Application/X-WWW-Form-Urlencoded; charset=utf-8")
+	c = NewContext(httptest.NewRecorder(), req, nil)
+	p = payload{}
+	if err := c.BindValidate(&p); err != nil || p.Name != "john" {
+		t.Errorf("Expected form to bind, got %+v (%v)", p, err)
+	}
+}
+
+func TestBindJSONPassthroughTargets(t *testing.T) {
+	const body = `{"name":"jane","tags":["a","b"],"meta":{"age":30}}`
+	newContext := func() *Context {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return NewContext(httptest.NewRecorder(), req, New())
+	}
+
+	// Raw bodies are kept verbatim for later reprocessing
+	var raw json.RawMessage
+	if err := newContext().BindJSON(&raw); err != nil {
+		t.Fatalf("Expected no error binding json.RawMessage, got %v", err)
+	}
+	if string(raw) != body {
+		t.Errorf("Expected raw body %s, got %s", body, raw)
+	}
+
+	var m map[string]any
+	if err := newContext().BindJSON(&m); err != nil {
+		t.Fatalf("Expected no error binding map, got %v", err)
+	}
+	if m["name"] != "jane" {
+		t.Errorf("Expected name jane, got %v", m["name"])
+	}
+	if meta, ok := m["meta"].(map[string]any); !ok || meta["age"] != float64(30) {
+		t.Errorf("Expected nested object, got %v", m["meta"])
+	}
+
+	if err := newContext().Bind(&m); err != nil {
+		t.Errorf("Expected Bind into map to succeed, got %v", err)
+	}
+	if err := newContext().BindValidate(&raw); err != nil {
+		t.Errorf("Expected BindValidate into json.RawMessage to succeed, got %v", err)
+	}
+}
+
+func TestBindJSONTopLevelArray(t *testing.T) {
+	type Item struct {
+		Name string `json:"name" validate:"required"`
+		Qty  int    `json:"qty" validate:"min=1"`
+	}
+	newContext := func(body string) *Context {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return NewContext(httptest.NewRecorder(), req, New())
+	}
+
+	var items []Item
+	if err := newContext(`[{"name":"a","qty":1},{"name":"b","qty":2}]`).BindJSON(&items); err != nil {
+		t.Fatalf("Expected valid array to bind, got %v", err)
+	}
+	if len(items) != 2 || items[1].Name != "b" {
+		t.Errorf("Expected 2 items, got %+v", items)
+	}
+
+	items = nil
+	err := newContext(`[{"name":"a","qty":1},{"name":"","qty":0}]`).BindJSON(&items)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	if strings.Join(fields, ",") != "[1].Name,[1].Qty" {
+		t.Errorf("Expected errors for [1].Name and [1].Qty, got %v", fields)
+	}
+}
+
+func TestAcceptEncoding(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+	tests := []struct {
+		name      string
+		header    *string
+		gzip      bool
+		br        bool
+		identity  bool
+		preferred string
+	}{
+		{"no header", nil, true, true, true, "br"},
+		{"empty header", ptr(""), false, false, true, "identity"},
+		{"gzip excluded", ptr("gzip;q=0, br"), false, true, true, "br"},
+		{"quality order", ptr("gzip;q=1.0, br;q=0.5"), true, true, true, "gzip"},
+		{"wildcard", ptr("*;q=0.5, gzip;q=0"), false, true, true, "br"},
+		{"wildcard excludes identity", ptr("gzip, *;q=0"), true, false, false, "gzip"},
+		{"identity listed", ptr("identity;q=0, *;q=0"), false, false, false, ""},
+		{"case insensitive", ptr("GZIP"), true, false, true, "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != nil {
+				req.Header.Set("Accept-Encoding", *tt.header)
+			}
+			c := NewContext(httptest.NewRecorder(), req, nil)
+			if got := c.AcceptsEncoding("gzip"); got != tt.gzip {
+				t.Errorf("Expected gzip %v, got %v", tt.gzip, got)
+			}
+			if got := c.AcceptsEncoding("br"); got != tt.br {
+				t.Errorf("Expected br %v, got %v", tt.br, got)
+			}
+			if got := c.AcceptsEncoding("identity"); got != tt.identity {
+				t.Errorf("Expected identity %v, got %v", tt.identity, got)
+			}
+			if got := c.PreferredEncoding("br", "gzip", "identity"); got != tt.preferred {
+				t.Errorf("Expected preferred %q, got %q", tt.preferred, got)
+			}
+		})
+	}
+}
+
 func TestNegotiate(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -98,6 +544,13 @@ func TestNegotiate(t *testing.T) {
 		{"Default", "", "json"},
 		{"Text", "text/plain", "text"},
 		{"HTML", "text/html", "html"},
+		{"Quality", "text/html;q=0.9, application/json;q=1.0", "json"},
+		{"Quality prefers HTML", "application/json;q=0.5, text/html", "html"},
+		{"Wildcard subtype", "text/*", "html"},
+		{"Wildcard with exclusion", "text/*, text/html;q=0", "text"},
+		{"Any", "*/*", "json"},
+		{"Any lower than specific", "*/*;q=0.1, text/plain", "text"},
+		{"No match falls back", "image/png", "json"},
 	}
 
 	for _, tt := range tests {
@@ -257,3 +710,359 @@ func TestBindForm(t *testing.T) {
 		t.Errorf("Expected password 'secret', got %s", data.Password)
 	}
 }
+
+func TestMaxMultipartMemory(t *testing.T) {
+	type UploadForm struct {
+		Title string `form:"title" validate:"required"`
+	}
+
+	app := New()
+	if app.MaxMultipartMemory != 32<<20 {
+		t.Errorf("Expected default MaxMultipartMemory of 32MB, got %d", app.MaxMultipartMemory)
+	}
+	app.MaxMultipartMemory = 16
+
+	app.Post("/upload", func(c *Context) error {
+		var form UploadForm
+		if err := c.BindValidate(&form); err != nil {
+			return c.Text(StatusBadRequest, err.Error())
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return c.Text(StatusBadRequest, err.Error())
+		}
+		f, err := fileHeader.Open()
+		if err != nil {
+			return c.Text(StatusInternalServerError, err.Error())
+		}
+		defer func() { _ = f.Close() }()
+		data, _ := io.ReadAll(f)
+
+		return c.Text(StatusOK, form.Title+":"+string(data))
+	})
+
+	content := strings.Repeat("x", 1024)
+	w := PerformMultipartRequest(app, "POST", "/upload",
+		map[string]string{"title": "report"},
+		map[string][]byte{"file": []byte(content)})
+
+	if w.Code != StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Body.String() != "report:"+content {
+		t.Errorf("Unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestMustBind(t *testing.T) {
+	type CreateUser struct {
+		Name string `json:"name" validate:"required"`
+		Age  int    `json:"age"`
+	}
+
+	app := New()
+	reached := false
+	app.Post("/users", func(c *Context) error {
+		var req CreateUser
+		if !c.MustBindJSON(&req) {
+			return nil
+		}
+		reached = true
+		return c.Text(http.StatusOK, req.Name)
+	})
+
+	w := PerformRequest(app, "POST", "/users", strings.NewReader(`{"name":"john"}`))
+	if w.Code != http.StatusOK || w.Body.String() != "john" {
+		t.Errorf("Expected 200 john, got %d %s", w.Code, w.Body.String())
+	}
+
+	reached = false
+	w = PerformRequest(app, "POST", "/users", strings.NewReader(`{"name":`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for malformed body, got %d", w.Code)
+	}
+	if reached {
+		t.Error("Expected handler to stop after failed bind")
+	}
+
+	w = PerformRequest(app, "POST", "/users", strings.NewReader(`{"age":3}`))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for validation failure, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"field":"Name"`) {
+		t.Errorf("Expected field errors in body, got %s", w.Body.String())
+	}
+}
+
+func TestBindNestedAndEmbeddedStructs(t *testing.T) {
+	type Pagination struct {
+		Page  int `query:"page" form:"page"`
+		Limit int `query:"limit" form:"limit"`
+	}
+	type Address struct {
+		City string `query:"city" form:"city"`
+		Zip  string `query:"zip" form:"zip"`
+	}
+	type Search struct {
+		Pagination
+		Query    string    `query:"q" form:"q"`
+		Address  Address   `query:"address" form:"address"`
+		Billing  *Address  `query:"billing" form:"billing"`
+		Shipping *Address  `query:"shipping" form:"shipping"`
+		Since    time.Time `query:"since" form:"since"`
+	}
+
+	req := httptest.NewRequest("GET", "/search?q=go&page=2&limit=20&address.city=Paris&address.zip=75001&billing.city=Lyon", nil)
+	c := NewContext(httptest.NewRecorder(), req, nil)
+
+	var s Search
+	if err := c.BindQuery(&s); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if s.Page != 2 || s.Limit != 20 {
+		t.Errorf("Expected embedded pagination 2/20, got %d/%d", s.Page, s.Limit)
+	}
+	if s.Query != "go" {
+		t.Errorf("Expected q 'go', got %s", s.Query)
+	}
+	if s.Address.City != "Paris" || s.Address.Zip != "75001" {
+		t.Errorf("Expected nested address Paris 75001, got %+v", s.Address)
+	}
+	if s.Billing == nil || s.Billing.City != "Lyon" {
+		t.Errorf("Expected billing pointer to be allocated and bound, got %+v", s.Billing)
+	}
+	if s.Shipping != nil {
+		t.Errorf("Expected shipping to stay nil without shipping.* keys, got %+v", s.Shipping)
+	}
+
+	// Conversion errors report the full nested key
+	req = httptest.NewRequest("GET", "/search?limit=x", nil)
+	c = NewContext(httptest.NewRecorder(), req, nil)
+	var fieldErrs BindFieldErrors
+	if err := c.BindQuery(&Search{}); !errors.As(err, &fieldErrs) || fieldErrs[0].Field != "limit" {
+		t.Errorf("Expected field error for limit, got %v", err)
+	}
+
+	// Form binding follows the same conventions
+	req = httptest.NewRequest("POST", "/search", strings.NewReader("page=3&address.city=Rome"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c = NewContext(httptest.NewRecorder(), req, nil)
+
+	s = Search{}
+	if err := c.BindValidate(&s); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if s.Page != 3 || s.Address.City != "Rome" {
+		t.Errorf("Expected form page 3 and city Rome, got %d %s", s.Page, s.Address.City)
+	}
+}
+
+func TestBindRepeatedParamsIntoSlices(t *testing.T) {
+	type Filter struct {
+		Tags   []string `query:"tag" form:"tag"`
+		IDs    []int    `query:"id" form:"id"`
+		Traces []string `header:"X-Trace"`
+	}
+
+	req := httptest.NewRequest("GET", "/items?tag=a&tag=b&id=1&id=2&id=3", nil)
+	req.Header.Add("X-Trace", "t1")
+	req.Header.Add("X-Trace", "t2")
+	c := NewContext(httptest.NewRecorder(), req, nil)
+
+	if got := c.QueryArray("tag"); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("Expected QueryArray [a b], got %v", got)
+	}
+	if got := c.QueryArray("missing"); len(got) != 0 {
+		t.Errorf("Expected empty QueryArray, got %v", got)
+	}
+
+	var f Filter
+	if err := c.BindQuery(&f); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(f.Tags) != 2 || f.Tags[1] != "b" {
+		t.Errorf("Expected tags [a b], got %v", f.Tags)
+	}
+	if len(f.IDs) != 3 || f.IDs[2] != 3 {
+		t.Errorf("Expected ids [1 2 3], got %v", f.IDs)
+	}
+	if err := c.BindHeader(&f); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(f.Traces) != 2 || f.Traces[0] != "t1" {
+		t.Errorf("Expected traces [t1 t2], got %v", f.Traces)
+	}
+
+	// An invalid element reports the key and leaves the field unset
+	c = NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/items?id=1&id=x", nil), nil)
+	f = Filter{}
+	var fieldErrs BindFieldErrors
+	if err := c.BindQuery(&f); !errors.As(err, &fieldErrs) || fieldErrs[0].Field != "id" {
+		t.Errorf("Expected field error for id, got %v", err)
+	}
+	if f.IDs != nil {
+		t.Errorf("Expected ids to stay unset, got %v", f.IDs)
+	}
+
+	// Form binding collects repeated keys too
+	req = httptest.NewRequest("POST", "/items", strings.NewReader("tag=x&tag=y"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c = NewContext(httptest.NewRecorder(), req, nil)
+	f = Filter{}
+	if err := c.BindValidate(&f); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(f.Tags) != 2 || f.Tags[0] != "x" {
+		t.Errorf("Expected form tags [x y], got %v", f.Tags)
+	}
+}
+
+func TestQueryMapAndBracketBinding(t *testing.T) {
+	type Filters map[string]string
+	type ListRequest struct {
+		Filter Filters           `query:"filter"`
+		Sort   map[string]string `query:"sort"`
+		Page   int               `query:"page"`
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected map[string]string
+	}{
+		{"grouped", "filter[name]=x&filter[age]=30&page=2", map[string]string{"name": "x", "age": "30"}},
+		{"missing", "page=2", map[string]string{}},
+		{"malformed", "filter[name=x&filter[]=y&filter[a][b]=z&filter=w&filters[c]=v", map[string]string{}},
+		{"first value wins", "filter[name]=x&filter[name]=y", map[string]string{"name": "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/items?"+tt.query, nil), nil)
+
+			got := c.QueryMap("filter")
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for k, v := range tt.expected {
+				if got[k] != v {
+					t.Errorf("Expected %s=%s, got %s", k, v, got[k])
+				}
+			}
+
+			var req ListRequest
+			if err := c.BindQuery(&req); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(req.Filter) != len(tt.expected) {
+				t.Errorf("Expected bound filter %v, got %v", tt.expected, req.Filter)
+			}
+			if req.Sort != nil {
+				t.Errorf("Expected sort to stay nil without sort[...] keys, got %v", req.Sort)
+			}
+		})
+	}
+}
+
+// level is a custom type that parses itself from text.
+type level int
+
+func (l *level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("must be low or high")
+	}
+	return nil
+}
+
+func TestBindTimeAndTextUnmarshaler(t *testing.T) {
+	type Report struct {
+		From     time.Time  `query:"from" time_format:"2006-01-02"`
+		To       *time.Time `query:"to" time_format:"2006-01-02"`
+		Created  time.Time  `query:"created"`
+		Level    level      `query:"level"`
+		MaxLevel *level     `query:"max_level"`
+	}
+
+	req := httptest.NewRequest("GET", "/reports?from=2024-01-15&to=2024-02-01&created=2024-01-15T10:30:00Z&level=high&max_level=low", nil)
+	c := NewContext(httptest.NewRecorder(), req, nil)
+
+	var r Report
+	if err := c.BindQuery(&r); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !r.From.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected from 2024-01-15, got %v", r.From)
+	}
+	if r.To == nil || r.To.Day() != 1 || r.To.Month() != time.February {
+		t.Errorf("Expected to 2024-02-01, got %v", r.To)
+	}
+	if !r.Created.Equal(time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected RFC 3339 created time, got %v", r.Created)
+	}
+	if r.Level != 2 {
+		t.Errorf("Expected level high (2), got %d", r.Level)
+	}
+	if r.MaxLevel == nil || *r.MaxLevel != 1 {
+		t.Errorf("Expected max level low (1), got %v", r.MaxLevel)
+	}
+
+	// Parse failures are reported per field
+	req = httptest.NewRequest("GET", "/reports?from=15/01/2024&level=medium", nil)
+	c = NewContext(httptest.NewRecorder(), req, nil)
+
+	var fieldErrs BindFieldErrors
+	if err := c.BindQuery(&Report{}); !errors.As(err, &fieldErrs) {
+		t.Fatalf("Expected BindFieldErrors, got %v", err)
+	}
+	fields := fieldErrs.Fields()
+	if fields["from"] != "not a valid time, expected format 2006-01-02" {
+		t.Errorf("Expected time format error, got %q", fields["from"])
+	}
+	if fields["level"] != "must be low or high" {
+		t.Errorf("Expected custom type error, got %q", fields["level"])
+	}
+}
+
+func TestResponseShortcuts(t *testing.T) {
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if got := encode(A()); got != "[]" {
+		t.Errorf("Expected empty array, got %s", got)
+	}
+	if got := encode(A(1, "two", H{"three": 3})); got != `[1,"two",{"three":3}]` {
+		t.Errorf("Expected mixed array, got %s", got)
+	}
+	if got := encode(Err("not allowed")); got != `{"error":"not allowed"}` {
+		t.Errorf("Expected error envelope, got %s", got)
+	}
+
+	errs := ValidationErrors{{Field: "Email", Message: "Email is required", Tag: "required"}}
+	expected := `{"error":"Validation failed","errors":[{"field":"Email","message":"Email is required","tag":"required"}]}`
+	if got := encode(ErrsH(errs)); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if got := encode(ErrsH(nil)); got != `{"error":"Validation failed","errors":[]}` {
+		t.Errorf("Expected empty errors list, got %s", got)
+	}
+
+	// The envelope matches the default error handler's response
+	var body map[string]any
+	_ = json.Unmarshal([]byte(encode(ErrorResponse{Error: "Validation failed", Errors: errs})), &body)
+	for key := range ErrsH(errs) {
+		if _, ok := body[key]; !ok {
+			t.Errorf("Expected ErrorResponse to have field %q", key)
+		}
+	}
+}
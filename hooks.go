@@ -1,14 +1,31 @@
 package ginji
 
+import (
+	"log"
+	"log/slog"
+	"runtime/debug"
+)
+
 // HookFunc represents a lifecycle hook function.
 type HookFunc func(*Context)
 
+// PanicHookFunc is called with the recovered value and stack trace
+// whenever a panic is recovered while handling a request.
+type PanicHookFunc func(c *Context, recovered any, stack string)
+
+// ResponseTransformFunc transforms a payload before c.JSON encodes it and
+// returns the value to encode instead.
+type ResponseTransformFunc func(c *Context, payload any) any
+
 // LifecycleHooks stores application lifecycle hooks.
 type LifecycleHooks struct {
-	onRequest  []HookFunc // Before routing
-	onRoute    []HookFunc // After route match, before handler
-	onResponse []HookFunc // After handler execution
-	onError    []HookFunc // On error
+	onRequest     []HookFunc              // Before routing
+	onRoute       []HookFunc              // After route match, before handler
+	onResponse    []HookFunc              // After handler execution
+	onError       []HookFunc              // On error
+	onPanic       []PanicHookFunc         // On recovered panic
+	afterResponse []HookFunc              // After the response is sent
+	transformers  []ResponseTransformFunc // Before JSON encoding
 }
 
 // OnRequest registers a hook that runs before routing.
@@ -21,16 +38,53 @@ func (e *Engine) OnRoute(hook HookFunc) {
 	e.hooks.onRoute = append(e.hooks.onRoute, hook)
 }
 
-// OnResponse registers a hook that runs after handler execution.
+// OnResponse registers a hook that runs after handler execution, while
+// the response can still be written. See AfterResponse for work that must
+// wait until the response is sent.
 func (e *Engine) OnResponse(hook HookFunc) {
 	e.hooks.onResponse = append(e.hooks.onResponse, hook)
 }
 
+// AfterResponse registers a hook that runs once the request is over: after
+// the handler chain, the OnResponse hooks and panic recovery, with the
+// response flushed to the client. StatusCode and ResponseSize report the
+// final values. It also runs for responses the engine sends without
+// routing, such as CleanPath redirects and request limit errors, which
+// skip OnRequest and OnResponse. The hook runs before c is returned to the
+// pool, so its data is still valid, but c must not be retained after the
+// hook returns; hand c.Copy() to goroutines instead.
+// A panic in a hook is recovered and logged.
+func (e *Engine) AfterResponse(hook HookFunc) {
+	e.hooks.afterResponse = append(e.hooks.afterResponse, hook)
+}
+
 // OnError registers a hook that runs when an error occurs.
 func (e *Engine) OnError(hook HookFunc) {
 	e.hooks.onError = append(e.hooks.onError, hook)
 }
 
+// OnPanic registers a hook that runs whenever a panic is recovered.
+// Hooks run before the error response is written, which makes them a good
+// place to report panics to an error tracker.
+func (e *Engine) OnPanic(hook PanicHookFunc) {
+	e.hooks.onPanic = append(e.hooks.onPanic, hook)
+}
+
+// ResponseTransformer registers a transformer for JSON response payloads,
+// including those of typed handlers. Transformers run in registration
+// order, each receiving the previous one's result. The status code is
+// already set, so a transformer can wrap only successful responses:
+//
+//	app.ResponseTransformer(func(c *ginji.Context, payload any) any {
+//		if c.StatusCode() >= 400 {
+//			return payload
+//		}
+//		return map[string]any{"data": payload}
+//	})
+func (e *Engine) ResponseTransformer(transform ResponseTransformFunc) {
+	e.hooks.transformers = append(e.hooks.transformers, transform)
+}
+
 // executeOnRequest runs all OnRequest hooks.
 func (e *Engine) executeOnRequest(c *Context) {
 	for _, hook := range e.hooks.onRequest {
@@ -57,3 +111,96 @@ func (e *Engine) executeOnResponse(c *Context) {
 		hook(c)
 	}
 }
+
+// executeAfterResponse flushes the response and runs all AfterResponse hooks.
+func (e *Engine) executeAfterResponse(c *Context) {
+	if len(e.hooks.afterResponse) == 0 {
+		return
+	}
+	if c.Written() {
+		_ = c.writer.FlushError()
+	}
+	for _, hook := range e.hooks.afterResponse {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					c.logger().Error("AfterResponse hook panicked", append(c.requestAttrs(), slog.Any("panic", r))...)
+				}
+			}()
+			hook(c)
+		}()
+	}
+}
+
+// executeOnPanic runs all OnPanic hooks.
+// A panic inside a hook is recovered and logged so it cannot crash the server.
+func (e *Engine) executeOnPanic(c *Context, recovered any, stack string) {
+	for _, hook := range e.hooks.onPanic {
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					log.Printf("OnPanic hook panicked: %v", err)
+				}
+			}()
+			hook(c, recovered, stack)
+		}()
+	}
+}
+
+// requestAttrs returns log attributes identifying the request of c, so a
+// logged panic can be matched with its access log entry: the request ID
+// set by the RequestID middleware, if any, the method, route and path.
+func (c *Context) requestAttrs() []any {
+	var attrs []any
+	if id := c.RequestID(); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if c.Req != nil {
+		attrs = append(attrs,
+			slog.String("method", c.Req.Method),
+			slog.String("route", c.RoutePattern()),
+			slog.String("path", c.Req.URL.Path),
+		)
+	}
+	return attrs
+}
+
+// safeGo runs fn in a new goroutine and recovers a panic in it, which
+// would otherwise crash the process. The panic is logged and, when the
+// goroutine serves the request of c, passed to the OnPanic hooks with a
+// copy of c taken when the goroutine starts.
+func safeGo(c *Context, fn func()) {
+	// Capture the request now: c may be reused once the request ends
+	logger := slog.Default()
+	var attrs []any
+	var snapshot *Context
+	if c != nil {
+		logger = c.logger()
+		attrs = c.requestAttrs()
+		if c.Req != nil {
+			snapshot = c.Copy()
+		}
+	}
+	go func() {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			stack := string(debug.Stack())
+			logger.Error("Recovered from panic in goroutine", append(attrs, slog.Any("panic", r), slog.String("stack", stack))...)
+			if snapshot != nil && snapshot.engine != nil {
+				snapshot.engine.executeOnPanic(snapshot, r, stack)
+			}
+		}()
+		fn()
+	}()
+}
+
+// transformResponse runs all response transformers on payload.
+func (e *Engine) transformResponse(c *Context, payload any) any {
+	for _, transform := range e.hooks.transformers {
+		payload = transform(c, payload)
+	}
+	return payload
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"os"
 	"time"
 )
@@ -76,7 +77,7 @@ func LoggerWithConfig(config LoggerConfig) Middleware {
 		}
 
 		// Add request ID if present
-		if reqID, exists := c.Get("request_id"); exists {
+		if reqID := c.RequestID(); reqID != "" {
 			logEntry["request_id"] = reqID
 		}
 
@@ -133,6 +134,29 @@ func LoggerWithConfig(config LoggerConfig) Middleware {
 	}
 }
 
+// SlowRequestLog returns a middleware that logs requests taking longer
+// than threshold at Warn level with the engine logger. It is much cheaper
+// than full access logging and surfaces only latency outliers; entries
+// carry the route pattern so slow endpoints group together.
+func SlowRequestLog(threshold time.Duration) Middleware {
+	return func(c *Context) error {
+		start := time.Now()
+		err := c.Next()
+
+		if duration := time.Since(start); duration > threshold {
+			c.logger().Warn("Slow request",
+				slog.String("method", c.Req.Method),
+				slog.String("route", c.RoutePattern()),
+				slog.String("path", c.Req.URL.Path),
+				slog.Int("status", c.StatusCode()),
+				slog.Duration("duration", duration),
+				slog.Duration("threshold", threshold),
+			)
+		}
+		return err
+	}
+}
+
 // getStatusColor returns ANSI color code based on HTTP status.
 func getStatusColor(status int) string {
 	switch {
@@ -2,19 +2,30 @@ package ginji
 
 import (
 	"compress/gzip"
+	"compress/zlib"
 	"crypto/rand"
 	"encoding/hex"
 	"io"
 	"log"
+	"math"
+	"mime"
 	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
-// RequestID adds a unique ID to the request context and header.
+// RequestID adds a unique ID to the request context and header. Read it
+// with Context.RequestID; it is also stored under the "request_id" key for
+// existing code that reads it with Get.
 func RequestID() Middleware {
 	return func(c *Context) error {
 		id := generateRandomID()
 		c.SetHeader("X-Request-ID", id)
+		c.SetKeyed(requestIDKey, id)
 		c.Set("request_id", id)
 		return c.Next()
 	}
@@ -41,7 +52,8 @@ func (w *gzipResponseWriter) Write(b []byte) (int, error) {
 // Compress enables Gzip compression for responses.
 func Compress() Middleware {
 	return func(c *Context) error {
-		if !strings.Contains(c.Req.Header.Get("Accept-Encoding"), "gzip") {
+		// Only compress for clients that ask for it explicitly
+		if c.Req.Header.Get("Accept-Encoding") == "" || !c.AcceptsEncoding("gzip") {
 			return c.Next()
 		}
 
@@ -63,7 +75,7 @@ func Compress() Middleware {
 		originalRes := c.Res
 		c.Res = gzw
 		c.SetHeader("Content-Encoding", "gzip")
-		c.SetHeader("Vary", "Accept-Encoding")
+		c.AddVary("Accept-Encoding")
 
 		err := c.Next()
 
@@ -72,3 +84,257 @@ func Compress() Middleware {
 		return err
 	}
 }
+
+// DecompressConfig defines configuration for the Decompress middleware.
+type DecompressConfig struct {
+	// MaxSize is the maximum number of decompressed bytes allowed in a
+	// request body. Reading past it fails, guarding against decompression
+	// bombs. Default: the engine's MaxDecompressedSize
+	MaxSize int64
+}
+
+// defaultMaxDecompressedSize is the default limit for decompressed request bodies.
+const defaultMaxDecompressedSize = 10 << 20 // 10 MB
+
+// Decompress transparently decodes request bodies sent with
+// Content-Encoding gzip or deflate, so binding sees the decompressed stream.
+func Decompress() Middleware {
+	return DecompressWithConfig(DecompressConfig{})
+}
+
+// DecompressWithConfig returns a Decompress middleware with custom configuration.
+func DecompressWithConfig(config DecompressConfig) Middleware {
+	return func(c *Context) error {
+		encoding := strings.ToLower(strings.TrimSpace(c.Req.Header.Get("Content-Encoding")))
+		if encoding == "" || encoding == "identity" || !hasBody(c.Req) {
+			return c.Next()
+		}
+
+		var reader io.ReadCloser
+		switch encoding {
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(c.Req.Body)
+			if err != nil {
+				c.AbortWithError(http.StatusBadRequest, NewHTTPError(http.StatusBadRequest, "Invalid gzip request body"))
+				return nil
+			}
+			reader = gz
+		case "deflate":
+			// HTTP deflate is a zlib stream (RFC 9110, section 8.4.1.2)
+			zr, err := zlib.NewReader(c.Req.Body)
+			if err != nil {
+				c.AbortWithError(http.StatusBadRequest, NewHTTPError(http.StatusBadRequest, "Invalid deflate request body"))
+				return nil
+			}
+			reader = zr
+		default:
+			c.AbortWithError(http.StatusUnsupportedMediaType, NewHTTPError(
+				http.StatusUnsupportedMediaType,
+				"Unsupported Content-Encoding: "+encoding,
+			))
+			return nil
+		}
+		defer func() {
+			if err := reader.Close(); err != nil {
+				log.Printf("Failed to close decompression reader: %v", err)
+			}
+		}()
+
+		// Replace the body so downstream binding reads decompressed bytes
+		maxSize := config.MaxSize
+		if maxSize <= 0 {
+			maxSize = c.maxDecompressedSize()
+		}
+		c.Req.Body = http.MaxBytesReader(c.Res, reader, maxSize)
+		c.Req.Header.Del("Content-Encoding")
+		c.Req.Header.Del("Content-Length")
+		c.Req.ContentLength = -1
+
+		return c.Next()
+	}
+}
+
+// IPFilterConfig defines configuration for the IPFilter middleware.
+// Entries are IP addresses or CIDR ranges such as "10.0.0.0/8".
+type IPFilterConfig struct {
+	// Allow lists the clients that may pass. Empty allows every client
+	// that is not denied.
+	Allow []string
+
+	// Deny lists the clients that are blocked.
+	Deny []string
+
+	// AllowOverridesDeny lets clients matching both lists pass, e.g. to
+	// deny a network except for a few addresses in it. By default deny
+	// wins.
+	AllowOverridesDeny bool
+}
+
+// IPFilter returns a middleware that blocks requests by client IP, as
+// resolved by Context.ClientIP, with 403 Forbidden. Requests whose client
+// IP cannot be parsed are blocked too. It panics if an entry is not a
+// valid IP address or CIDR range.
+//
+//	admin.Use(ginji.IPFilter(ginji.IPFilterConfig{Allow: []string{"10.0.0.0/8"}}))
+func IPFilter(config IPFilterConfig) Middleware {
+	allow, err := parsePrefixes(config.Allow)
+	if err != nil {
+		panic(err)
+	}
+	deny, err := parsePrefixes(config.Deny)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(c *Context) error {
+		if ip, err := netip.ParseAddr(c.ClientIP()); err == nil {
+			ip = ip.Unmap()
+			allowed := prefixesContain(allow, ip)
+			denied := prefixesContain(deny, ip)
+			if config.AllowOverridesDeny && allowed {
+				return c.Next()
+			}
+			if !denied && (len(allow) == 0 || allowed) {
+				return c.Next()
+			}
+		}
+
+		c.AbortWithError(http.StatusForbidden, NewHTTPError(http.StatusForbidden))
+		return nil
+	}
+}
+
+// StripPrefixConfig defines configuration for the StripPrefix middleware.
+type StripPrefixConfig struct {
+	// Prefix is removed from the request path, e.g. "/service" when a
+	// reverse proxy mounts the app below it.
+	Prefix string
+
+	// Require rejects requests whose path lacks Prefix with 404 Not Found.
+	// Otherwise they are routed unchanged.
+	Require bool
+}
+
+// StripPrefix removes prefix from the request path before routing, so an
+// app served below a reverse-proxy mount point can register its routes
+// without it. It must be registered with Engine.Pre, not Use, because
+// routing has already happened by the time Use middleware run:
+//
+//	app.Pre(ginji.StripPrefix("/service"))
+//	app.Get("/users", listUsers) // serves /service/users
+func StripPrefix(prefix string) Middleware {
+	return StripPrefixWithConfig(StripPrefixConfig{Prefix: prefix})
+}
+
+// StripPrefixWithConfig returns a StripPrefix middleware with custom configuration.
+func StripPrefixWithConfig(config StripPrefixConfig) Middleware {
+	prefix := strings.TrimSuffix(config.Prefix, "/")
+
+	return func(c *Context) error {
+		if prefix == "" {
+			return c.Next()
+		}
+
+		path := c.Req.URL.Path
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			if config.Require {
+				c.AbortWithError(http.StatusNotFound, NewHTTPError(http.StatusNotFound))
+				return nil
+			}
+			return c.Next()
+		}
+
+		// Copy the request so the original URL stays intact for the caller
+		req := new(http.Request)
+		*req = *c.Req
+		u := *c.Req.URL
+		u.Path = strings.TrimPrefix(path, prefix)
+		if u.Path == "" {
+			u.Path = "/"
+		}
+		if rawPath, ok := strings.CutPrefix(u.RawPath, prefix); ok && rawPath != "" {
+			u.RawPath = rawPath
+		} else {
+			u.RawPath = ""
+		}
+		req.URL = &u
+		c.Req = req
+
+		return c.Next()
+	}
+}
+
+// RequireContentType returns a middleware that rejects POST, PUT and PATCH
+// requests with a body whose Content-Type is not one of types with
+// 415 Unsupported Media Type. Parameters such as charset are ignored, and
+// a type may end in "/*" to allow a whole family, e.g. "image/*".
+func RequireContentType(types ...string) Middleware {
+	return func(c *Context) error {
+		switch c.Req.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			return c.Next()
+		}
+		if !hasBody(c.Req) {
+			return c.Next()
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.Req.Header.Get("Content-Type"))
+		if err == nil && contentTypeAllowed(mediaType, types) {
+			return c.Next()
+		}
+
+		c.AbortWithError(http.StatusUnsupportedMediaType, NewHTTPError(
+			http.StatusUnsupportedMediaType,
+			"Unsupported Content-Type, expected one of: "+strings.Join(types, ", "),
+		))
+		return nil
+	}
+}
+
+// contentTypeAllowed reports whether mediaType, without parameters,
+// matches an entry of allowed, case-insensitively. Entries may carry
+// parameters, which are ignored, and may be "*/*" or end in "/*" to match
+// a whole family, e.g. "image/*". It is shared by RequireContentType and
+// SaveFormFile.
+func contentTypeAllowed(mediaType string, allowed []string) bool {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, a := range allowed {
+		a, _, _ = strings.Cut(a, ";")
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == mediaType || a == "*/*" {
+			return true
+		}
+		if family, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(mediaType, family+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Maintenance returns a middleware that, while enabled is set, responds to
+// every request with 503 Service Unavailable and a Retry-After header.
+// Requests to the allow paths (e.g. health checks) are still served.
+// Flipping enabled at runtime toggles maintenance mode without a redeploy:
+//
+//	var maintenance atomic.Bool
+//	app.Use(app.Maintenance(&maintenance, 5*time.Minute, "/health"))
+//	maintenance.Store(true)
+func (engine *Engine) Maintenance(enabled *atomic.Bool, retryAfter time.Duration, allow ...string) Middleware {
+	retrySeconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+
+	return func(c *Context) error {
+		if !enabled.Load() || slices.Contains(allow, c.Req.URL.Path) {
+			return c.Next()
+		}
+
+		if retryAfter > 0 {
+			c.SetHeader("Retry-After", retrySeconds)
+		}
+		c.AbortWithError(http.StatusServiceUnavailable, NewHTTPError(
+			http.StatusServiceUnavailable,
+			"Service is under maintenance",
+		))
+		return nil
+	}
+}
@@ -1,11 +1,17 @@
 package ginji
 
 import (
+	"bytes"
 	"compress/gzip"
+	"compress/zlib"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestRequestID(t *testing.T) {
@@ -51,4 +57,451 @@ func TestCompress(t *testing.T) {
 	if string(body) != "compressed content" {
 		t.Errorf("Expected compressed content, got %s", string(body))
 	}
+
+	// gzip;q=0 refuses gzip even though the header mentions it
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip;q=0, br")
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "compressed content" {
+		t.Errorf("Expected uncompressed response, got encoding %q body %q", w.Header().Get("Content-Encoding"), w.Body.String())
+	}
+}
+
+func gzipBytes(t *testing.T, data []byte) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestDecompress(t *testing.T) {
+	app := New()
+	app.Use(Decompress())
+	app.Post("/", func(c *Context) error {
+		var data map[string]string
+		if err := c.BindJSON(&data); err != nil {
+			return c.Text(http.StatusBadRequest, err.Error())
+		}
+		return c.Text(http.StatusOK, data["name"])
+	})
+
+	req := httptest.NewRequest("POST", "/", gzipBytes(t, []byte(`{"name":"ginji"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "ginji" {
+		t.Errorf("Expected 200 ginji, got %d %s", w.Code, w.Body.String())
+	}
+
+	// deflate is zlib-wrapped
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	_, _ = zw.Write([]byte(`{"name":"deflated"}`))
+	_ = zw.Close()
+	req = httptest.NewRequest("POST", "/", &deflated)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "deflate")
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "deflated" {
+		t.Errorf("Expected 200 deflated, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Invalid deflate stream
+	req = httptest.NewRequest("POST", "/", bytes.NewBufferString("not zlib"))
+	req.Header.Set("Content-Encoding", "deflate")
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid deflate, got %d", w.Code)
+	}
+
+	// Unsupported encoding
+	req = httptest.NewRequest("POST", "/", bytes.NewBufferString("data"))
+	req.Header.Set("Content-Encoding", "br")
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415, got %d", w.Code)
+	}
+
+	// Invalid gzip stream
+	req = httptest.NewRequest("POST", "/", bytes.NewBufferString("not gzip"))
+	req.Header.Set("Content-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", w.Code)
+	}
+}
+
+func TestDecompressMaxSize(t *testing.T) {
+	app := New()
+	app.Use(DecompressWithConfig(DecompressConfig{MaxSize: 16}))
+	app.Post("/", func(c *Context) error {
+		if _, err := io.ReadAll(c.Req.Body); err != nil {
+			return c.Text(http.StatusRequestEntityTooLarge, "too large")
+		}
+		return c.Text(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest("POST", "/", gzipBytes(t, bytes.Repeat([]byte("a"), 1024)))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d", w.Code)
+	}
+}
+
+func TestDecompressEngineMaxSize(t *testing.T) {
+	app := New()
+	app.MaxDecompressedSize = 64
+	app.Use(Decompress())
+	app.Post("/", func(c *Context) error {
+		var body map[string]string
+		if err := c.BindJSON(&body); err != nil {
+			c.AbortWithError(http.StatusBadRequest, HumanizeBindError(err))
+			return nil
+		}
+		return c.Text(http.StatusOK, body["name"])
+	})
+
+	send := func(payload []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/", gzipBytes(t, payload))
+		req.Header.Set("Content-Encoding", "gzip")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send([]byte(`{"name":"jane"}`)); w.Code != http.StatusOK || w.Body.String() != "jane" {
+		t.Errorf("Expected small body to bind, got %d %q", w.Code, w.Body.String())
+	}
+	bomb := []byte(`{"name":"` + strings.Repeat("a", 1<<20) + `"}`)
+	if w := send(bomb); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for body expanding past the limit, got %d", w.Code)
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	app := New()
+	var maintenance atomic.Bool
+	app.Use(app.Maintenance(&maintenance, 90*time.Second, "/health"))
+	app.Get("/", func(c *Context) error {
+		return c.Text(http.StatusOK, "ok")
+	})
+	app.Get("/health", func(c *Context) error {
+		return c.Text(http.StatusOK, "healthy")
+	})
+
+	w := PerformRequest(app, "GET", "/", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 while maintenance is off, got %d", w.Code)
+	}
+
+	maintenance.Store(true)
+
+	w = PerformRequest(app, "GET", "/", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 during maintenance, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "90" {
+		t.Errorf("Expected Retry-After 90, got %q", w.Header().Get("Retry-After"))
+	}
+
+	w = PerformRequest(app, "GET", "/health", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected allowlisted path to be served, got %d", w.Code)
+	}
+
+	maintenance.Store(false)
+	w = PerformRequest(app, "GET", "/", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 after maintenance ends, got %d", w.Code)
+	}
+}
+
+func TestRequireContentType(t *testing.T) {
+	app := New()
+	app.Use(RequireContentType("application/json", "image/*"))
+	ok := func(c *Context) error { return c.Text(http.StatusOK, "ok") }
+	app.Post("/upload", ok)
+	app.Get("/upload", ok)
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		expected    int
+	}{
+		{"json", "POST", "application/json", "{}", http.StatusOK},
+		{"json with charset", "POST", "Application/JSON; charset=utf-8", "{}", http.StatusOK},
+		{"image family", "POST", "image/png", "png", http.StatusOK},
+		{"xml rejected", "POST", "application/xml", "<a/>", http.StatusUnsupportedMediaType},
+		{"missing content type", "POST", "", "{}", http.StatusUnsupportedMediaType},
+		{"empty body", "POST", "", "", http.StatusOK},
+		{"read method", "GET", "text/plain", "x", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/upload", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestContentTypeAllowed(t *testing.T) {
+	allowed := []string{"Application/JSON", "image/*", "text/plain; charset=utf-8"}
+	tests := map[string]bool{
+		"application/json": true,
+		"IMAGE/PNG":        true,
+		"text/plain":       true,
+		"imagex/png":       false,
+		"text/html":        false,
+	}
+	for mediaType, expected := range tests {
+		if got := contentTypeAllowed(mediaType, allowed); got != expected {
+			t.Errorf("%s: expected %v, got %v", mediaType, expected, got)
+		}
+	}
+	if !contentTypeAllowed("video/mp4", []string{"*/*"}) {
+		t.Error("Expected */* to allow any type")
+	}
+}
+
+func TestStripPrefix(t *testing.T) {
+	app := New()
+	app.Pre(StripPrefix("/service/"))
+	api := app.Group("/api")
+	api.Use(func(c *Context) error {
+		c.SetHeader("X-Group", "api")
+		return c.Next()
+	})
+	api.Get("/users", func(c *Context) error { return c.Text(http.StatusOK, c.Req.URL.Path) })
+	app.Get("/", func(c *Context) error { return c.Text(http.StatusOK, "root") })
+
+	tests := []struct {
+		name     string
+		path     string
+		expected int
+		body     string
+	}{
+		{"stripped", "/service/api/users", http.StatusOK, "/api/users"},
+		{"prefix only", "/service", http.StatusOK, "root"},
+		{"lenient passthrough", "/api/users", http.StatusOK, "/api/users"},
+		{"partial segment", "/serviceapi/users", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/service/api/users", nil))
+	if w.Header().Get("X-Group") != "api" {
+		t.Errorf("Expected group middleware selected by stripped path, got %q", w.Header().Get("X-Group"))
+	}
+
+	strict := New()
+	strict.Pre(StripPrefixWithConfig(StripPrefixConfig{Prefix: "/service", Require: true}))
+	strict.Get("/users", func(c *Context) error { return c.Text(http.StatusOK, "ok") })
+
+	w = httptest.NewRecorder()
+	strict.ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without prefix, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	strict.ServeHTTP(w, httptest.NewRequest("GET", "/service/users", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 with prefix, got %d", w.Code)
+	}
+}
+
+func TestSlowRequestLog(t *testing.T) {
+	var logs bytes.Buffer
+	app := New()
+	app.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	app.Use(SlowRequestLog(20 * time.Millisecond))
+	app.Get("/users/:id", func(c *Context) error {
+		if c.Query("slow") != "" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		return c.Text(http.StatusAccepted, "ok")
+	})
+
+	PerformRequest(app, "GET", "/users/1", nil)
+	if logs.Len() != 0 {
+		t.Errorf("Expected fast request not to be logged, got %q", logs.String())
+	}
+
+	PerformRequest(app, "GET", "/users/2?slow=1", nil)
+	line := logs.String()
+	for _, want := range []string{"level=WARN", "msg=\"Slow request\"", "method=GET", "route=/users/:id", "path=/users/2", "status=202", "duration="} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected log to contain %q, got %q", want, line)
+		}
+	}
+}
+
+func TestIPFilter(t *testing.T) {
+	ok := func(c *Context) error { return c.Text(http.StatusOK, "ok") }
+	request := func(app *Engine, remote, forwarded string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote
+		if forwarded != "" {
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	app := New()
+	app.Use(IPFilter(IPFilterConfig{
+		Allow: []string{"10.0.0.0/8", "192.168.1.10"},
+		Deny:  []string{"10.0.0.5"},
+	}))
+	app.Get("/", ok)
+
+	tests := []struct {
+		name     string
+		remote   string
+		expected int
+	}{
+		{"allowed range", "10.1.2.3:1234", http.StatusOK},
+		{"allowed address", "192.168.1.10:1234", http.StatusOK},
+		{"deny wins", "10.0.0.5:1234", http.StatusForbidden},
+		{"not allowed", "8.8.8.8:1234", http.StatusForbidden},
+		{"mapped IPv4", "[::ffff:10.1.2.3]:1234", http.StatusOK},
+		{"unparsable", "garbage", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := request(app, tt.remote, ""); code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, code)
+			}
+		})
+	}
+
+	// Empty allow list allows everyone not denied; allow can override deny
+	open := New()
+	open.Use(IPFilter(IPFilterConfig{Deny: []string{"10.0.0.0/8"}, Allow: nil}))
+	open.Get("/", ok)
+	if code := request(open, "8.8.8.8:1", ""); code != http.StatusOK {
+		t.Errorf("Expected empty allow list to allow, got %d", code)
+	}
+	if code := request(open, "10.0.0.1:1", ""); code != http.StatusForbidden {
+		t.Errorf("Expected denied range to be blocked, got %d", code)
+	}
+
+	override := New()
+	override.Use(IPFilter(IPFilterConfig{Deny: []string{"10.0.0.0/8"}, Allow: []string{"10.0.0.7"}, AllowOverridesDeny: true}))
+	override.Get("/", ok)
+	if code := request(override, "10.0.0.7:1", ""); code != http.StatusOK {
+		t.Errorf("Expected allow to override deny, got %d", code)
+	}
+	if code := request(override, "10.0.0.8:1", ""); code != http.StatusForbidden {
+		t.Errorf("Expected other denied addresses to be blocked, got %d", code)
+	}
+
+	// Forwarded addresses only count behind a trusted proxy
+	proxied := New()
+	if err := proxied.SetTrustedProxies("127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	proxied.Use(IPFilter(IPFilterConfig{Allow: []string{"203.0.113.0/24"}}))
+	proxied.Get("/", ok)
+	if code := request(proxied, "127.0.0.1:1", "198.51.100.1, 203.0.113.9"); code != http.StatusOK {
+		t.Errorf("Expected forwarded client behind trusted proxy to pass, got %d", code)
+	}
+	if code := request(proxied, "198.51.100.2:1", "203.0.113.9"); code != http.StatusForbidden {
+		t.Errorf("Expected spoofed X-Forwarded-For to be ignored, got %d", code)
+	}
+}
+
+func TestChainStopsAfterResponseWritten(t *testing.T) {
+	var ran []string
+	app := New()
+	app.Use(func(c *Context) error {
+		err := c.Next()
+		ran = append(ran, "outer")
+		return err
+	})
+	// Answers cache hits itself and falls through without Abort
+	app.Use(func(c *Context) error {
+		if c.Query("cached") != "" {
+			return c.Text(http.StatusOK, "from cache")
+		}
+		if c.Query("deny") != "" {
+			c.Status(http.StatusForbidden)
+		}
+		return nil
+	})
+	app.Use(func(c *Context) error {
+		ran = append(ran, "later")
+		return nil
+	})
+	app.Get("/", func(c *Context) error {
+		ran = append(ran, "handler")
+		return c.Text(http.StatusOK, "from handler")
+	})
+
+	w := PerformRequest(app, "GET", "/?cached=1", nil)
+	if w.Body.String() != "from cache" {
+		t.Errorf("Expected single cached body, got %q", w.Body.String())
+	}
+	if strings.Join(ran, ",") != "outer" {
+		t.Errorf("Expected later handlers to be skipped, got %v", ran)
+	}
+
+	// A status without a body ends the chain too
+	ran = nil
+	w = PerformRequest(app, "GET", "/?deny=1", nil)
+	if w.Code != http.StatusForbidden || w.Body.String() != "" {
+		t.Errorf("Expected bare 403, got %d %q", w.Code, w.Body.String())
+	}
+	if strings.Join(ran, ",") != "outer" {
+		t.Errorf("Expected later handlers to be skipped after Status, got %v", ran)
+	}
+
+	ran = nil
+	w = PerformRequest(app, "GET", "/", nil)
+	if w.Body.String() != "from handler" || strings.Join(ran, ",") != "later,handler,outer" {
+		t.Errorf("Expected full chain, got %q %v", w.Body.String(), ran)
+	}
 }
@@ -0,0 +1,96 @@
+package ginji
+
+import (
+	"io"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// MultipartWriter writes a multipart/mixed response, e.g. for batch APIs
+// that combine several sub-responses. Each part is flushed to the client
+// as soon as it is written, so parts can be streamed as they are produced.
+type MultipartWriter struct {
+	c *Context
+	w *multipart.Writer
+}
+
+// Multipart starts a multipart/mixed response and sets its Content-Type
+// with the generated boundary. Set a status with c.Status before writing
+// the first part if it should not be 200 OK, and Close the writer to
+// finish the response:
+//
+//	mw := c.Multipart()
+//	for _, op := range batch {
+//		if err := mw.WriteJSONPart(run(op)); err != nil {
+//			return err
+//		}
+//	}
+//	return mw.Close()
+func (c *Context) Multipart() *MultipartWriter {
+	w := multipart.NewWriter(c.Res)
+	c.SetHeader("Content-Type", "multipart/mixed; boundary="+w.Boundary())
+	return &MultipartWriter{c: c, w: w}
+}
+
+// Boundary returns the boundary separating the parts.
+func (m *MultipartWriter) Boundary() string {
+	return m.w.Boundary()
+}
+
+// CreatePart starts a new part with the given headers and returns a writer
+// for its body. The body is flushed when the next part is created or the
+// writer is closed.
+func (m *MultipartWriter) CreatePart(header textproto.MIMEHeader) (io.Writer, error) {
+	m.c.written = true
+	if err := flush(m.c.Res); err != nil {
+		return nil, m.c.streamError(err)
+	}
+	part, err := m.w.CreatePart(header)
+	if err != nil {
+		return nil, m.c.streamError(err)
+	}
+	return part, nil
+}
+
+// WritePart writes a complete part with the given content type and body.
+func (m *MultipartWriter) WritePart(contentType string, body []byte) error {
+	header := make(textproto.MIMEHeader)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	part, err := m.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(body); err != nil {
+		return m.c.streamError(err)
+	}
+	return m.flush()
+}
+
+// WriteJSONPart writes v as an application/json part, encoded with the
+// same options as Context.JSON.
+func (m *MultipartWriter) WriteJSONPart(v any) error {
+	body, err := jsonMarshal(v)
+	if err != nil {
+		return err
+	}
+	return m.WritePart("application/json", body)
+}
+
+// Close writes the closing boundary and flushes the response.
+func (m *MultipartWriter) Close() error {
+	m.c.written = true
+	if err := m.w.Close(); err != nil {
+		return m.c.streamError(err)
+	}
+	return m.flush()
+}
+
+// flush sends the parts written so far to the client.
+func (m *MultipartWriter) flush() error {
+	if err := flush(m.c.Res); err != nil {
+		return m.c.streamError(err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package ginji
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestMultipartResponse(t *testing.T) {
+	app := New()
+	app.Post("/batch", func(c *Context) error {
+		mw := c.Multipart()
+		c.Status(http.StatusMultiStatus)
+		if err := mw.WriteJSONPart(map[string]int{"id": 1}); err != nil {
+			return err
+		}
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"text/plain"},
+			"Content-Id":   {"<op-2>"},
+		})
+		if err != nil {
+			return err
+		}
+		_, _ = io.WriteString(part, "second")
+		return mw.Close()
+	})
+
+	w := PerformRequest(app, "POST", "/batch", nil)
+	AssertStatus(t, w, http.StatusMultiStatus)
+
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Expected multipart/mixed, got %q (%v)", w.Header().Get("Content-Type"), err)
+	}
+
+	r := multipart.NewReader(w.Body, params["boundary"])
+	expected := []struct {
+		contentType string
+		body        string
+	}{
+		{"application/json", `{"id":1}`},
+		{"text/plain", "second"},
+	}
+	for i, exp := range expected {
+		part, err := r.NextPart()
+		if err != nil {
+			t.Fatalf("Part %d: %v", i, err)
+		}
+		body, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Type") != exp.contentType {
+			t.Errorf("Part %d: expected Content-Type %s, got %s", i, exp.contentType, part.Header.Get("Content-Type"))
+		}
+		if strings.TrimSpace(string(body)) != exp.body {
+			t.Errorf("Part %d: expected body %q, got %q", i, exp.body, body)
+		}
+	}
+	if _, err := r.NextPart(); err != io.EOF {
+		t.Errorf("Expected end of multipart body, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,43 +17,192 @@ type NegotiateFormat struct {
 	Text func() error
 }
 
-// Negotiate performs content negotiation based on Accept header.
+// Negotiate performs content negotiation based on the Accept header.
+// Quality values and media ranges such as "text/*" and "*/*" are honored,
+// and the supported format with the highest quality is chosen; ties go to
+// the order JSON, XML, HTML, Text. JSON, HTML and Text are always supported,
+// falling back to built-in rendering of data when no function is given;
+// XML is only offered when configured. When nothing acceptable matches,
+// the first configured format is used.
 func (c *Context) Negotiate(code int, data interface{}, formats NegotiateFormat) error {
-	accept := c.Header("Accept")
+	render := func(fn func() error, fallback func() error) func() error {
+		if fn != nil {
+			return fn
+		}
+		return fallback
+	}
+	text := func() error { return c.Text(code, fmt.Sprintf("%v", data)) }
+	c.AddVary("Accept")
+
+	type offer struct {
+		mediaTypes []string
+		configured bool
+		render     func() error
+	}
+	offers := []offer{
+		{[]string{"application/json"}, formats.JSON != nil, render(formats.JSON, func() error { return c.JSON(code, data) })},
+		{[]string{"application/xml", "text/xml"}, formats.XML != nil, formats.XML},
+		{[]string{"text/html"}, formats.HTML != nil, render(formats.HTML, text)},
+		{[]string{"text/plain"}, formats.Text != nil, render(formats.Text, text)},
+	}
+
+	ranges := parseAccept(c.Header("Accept"))
+	var best *offer
+	bestQ := 0.0
+	for i := range offers {
+		o := &offers[i]
+		if o.render == nil {
+			continue
+		}
+		for _, mediaType := range o.mediaTypes {
+			if q := acceptQuality(ranges, mediaType); q > bestQ {
+				best, bestQ = o, q
+			}
+		}
+	}
+	if best != nil {
+		return best.render()
+	}
+
+	// Nothing acceptable: fall back to the first configured format
+	for _, o := range offers {
+		if o.configured {
+			return o.render()
+		}
+	}
+	return offers[0].render()
+}
+
+// acceptRange is a media range from an Accept header.
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept parses an Accept header into media ranges. An empty header
+// accepts everything.
+func parseAccept(header string) []acceptRange {
+	if strings.TrimSpace(header) == "" {
+		return []acceptRange{{typ: "*", subtype: "*", q: 1}}
+	}
 
-	// Determine preferred content type
-	switch {
-	case strings.Contains(accept, "application/json") || accept == "*/*" || accept == "":
-		if formats.JSON != nil {
-			return formats.JSON()
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok || typ == "" || subtype == "" {
+			continue
 		}
-		return c.JSON(code, data)
 
-	case strings.Contains(accept, "application/xml") || strings.Contains(accept, "text/xml"):
-		if formats.XML != nil {
-			return formats.XML()
+		r := acceptRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil && q >= 0 && q <= 1 {
+					r.q = q
+				}
+			}
 		}
-		return c.Text(code, "XML not supported")
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
 
-	case strings.Contains(accept, "text/html"):
-		if formats.HTML != nil {
-			return formats.HTML()
+// acceptQuality returns the quality the ranges assign to mediaType, taken
+// from the most specific matching range, or 0 when none matches.
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		default:
+			continue
 		}
-		return c.Text(code, fmt.Sprintf("%v", data))
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// AcceptsEncoding reports whether the client accepts responses with the
+// content coding enc, e.g. "gzip" or "br", according to Accept-Encoding.
+// Without the header every coding is acceptable. "identity" (no coding)
+// is acceptable unless excluded with "identity;q=0" or "*;q=0".
+func (c *Context) AcceptsEncoding(enc string) bool {
+	return c.encodingQuality(strings.ToLower(enc)) > 0
+}
 
-	case strings.Contains(accept, "text/plain"):
-		if formats.Text != nil {
-			return formats.Text()
+// PreferredEncoding returns the coding from supported with the highest
+// quality in Accept-Encoding, with ties going to the earlier entry. It
+// returns "" if none is acceptable, so list "identity" last to fall back
+// to an uncompressed response:
+//
+//	switch c.PreferredEncoding("br", "gzip", "identity") {
+//	case "br":
+//		// ...
+//	}
+func (c *Context) PreferredEncoding(supported ...string) string {
+	best, bestQ := "", 0.0
+	for _, enc := range supported {
+		if q := c.encodingQuality(strings.ToLower(enc)); q > bestQ {
+			best, bestQ = enc, q
 		}
-		return c.Text(code, fmt.Sprintf("%v", data))
+	}
+	return best
+}
 
-	default:
-		// Default to JSON
-		if formats.JSON != nil {
-			return formats.JSON()
+// encodingQuality returns the quality Accept-Encoding assigns to the
+// lower-cased coding enc, preferring an exact entry over "*".
+func (c *Context) encodingQuality(enc string) float64 {
+	values, ok := c.Req.Header["Accept-Encoding"]
+	if !ok {
+		return 1
+	}
+	codings := parseAcceptEncoding(strings.Join(values, ","))
+	if q, ok := codings[enc]; ok {
+		return q
+	}
+	if q, ok := codings["*"]; ok {
+		return q
+	}
+	if enc == "identity" {
+		return 1
+	}
+	return 0
+}
+
+// parseAcceptEncoding parses an Accept-Encoding header into the quality of
+// each lower-cased coding.
+func parseAcceptEncoding(header string) map[string]float64 {
+	codings := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if coding == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil && v >= 0 && v <= 1 {
+					q = v
+				}
+			}
 		}
-		return c.JSON(code, data)
+		codings[coding] = q
 	}
+	return codings
 }
 
 // CacheConfig represents cache configuration.
@@ -168,6 +318,7 @@ func (c *Context) ETag(content string) *Context {
 	// Check If-None-Match header
 	if c.Header("If-None-Match") == `"`+etag+`"` {
 		c.writer.WriteHeader(304)
+		c.writer.writeHeaderNow()
 	}
 
 	return c
@@ -3,7 +3,11 @@ package ginji
 import (
 	"encoding/json"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // OpenAPIInfo represents API information.
@@ -226,6 +230,74 @@ func (r *Router) generatePaths(spec *OpenAPISpec) {
 	for method, root := range r.roots {
 		r.traverseNode(root, method, "", spec)
 	}
+	assignOperationIDs(spec)
+}
+
+// assignOperationIDs generates an operation ID, e.g. "getUsersId" for
+// GET /users/:id, for every operation without an explicit one. IDs are
+// unique across the spec; collisions get a numeric suffix.
+func assignOperationIDs(spec *OpenAPISpec) {
+	type entry struct {
+		method, path string
+		op           *OpenAPIOperation
+	}
+
+	// Visit operations in a stable order so generated IDs are deterministic
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var entries []entry
+	used := make(map[string]bool)
+	for _, path := range paths {
+		item := spec.Paths[path]
+		for _, mo := range []struct {
+			method string
+			op     *OpenAPIOperation
+		}{
+			{"get", item.Get}, {"post", item.Post}, {"put", item.Put},
+			{"delete", item.Delete}, {"patch", item.Patch},
+			{"options", item.Options}, {"head", item.Head},
+		} {
+			if mo.op == nil {
+				continue
+			}
+			if mo.op.OperationID != "" {
+				used[mo.op.OperationID] = true
+				continue
+			}
+			entries = append(entries, entry{mo.method, path, mo.op})
+		}
+	}
+
+	for _, e := range entries {
+		base := operationIDFor(e.method, e.path)
+		id := base
+		for n := 2; used[id]; n++ {
+			id = base + strconv.Itoa(n)
+		}
+		used[id] = true
+		e.op.OperationID = id
+	}
+}
+
+// operationIDFor builds a camelCase operation ID from a method and pattern.
+func operationIDFor(method, pattern string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range strings.FieldsFunc(pattern, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		first, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(word[size:])
+	}
+	if b.Len() == len(method) {
+		b.WriteString("Root")
+	}
+	return b.String()
 }
 
 // traverseNode traverses the trie and generates path items.
@@ -246,7 +318,8 @@ func (r *Router) traverseNode(node *node, method string, currentPath string, spe
 	// If this node has a pattern (is a route endpoint)
 	if node.pattern != "" {
 		// Get or create path item
-		pathItem, exists := spec.Paths[node.pattern]
+		path := openAPIPath(node.pattern)
+		pathItem, exists := spec.Paths[path]
 		if !exists {
 			pathItem = OpenAPIPathItem{}
 		}
@@ -263,19 +336,43 @@ func (r *Router) traverseNode(node *node, method string, currentPath string, spe
 			OperationID: metadata.OperationID,
 			Responses:   make(map[string]OpenAPIResponse),
 			Deprecated:  metadata.Deprecated,
+			Security:    metadata.Security,
+		}
+
+		// Describe parameters declared on the request types
+		typed := structParameters(metadata.ParamsType, spec.Components.Schemas)
+		if metadata.RequestType != metadata.ParamsType {
+			typed = append(typed, structParameters(metadata.RequestType, spec.Components.Schemas)...)
+		}
+		declared := make(map[string]OpenAPIParameter, len(typed))
+		for _, param := range typed {
+			declared[param.In+":"+param.Name] = param
 		}
 
 		// Add path parameters
 		params := extractPathParameters(node.pattern)
 		for _, param := range params {
-			operation.Parameters = append(operation.Parameters, OpenAPIParameter{
+			if p, ok := declared["path:"+param]; ok {
+				operation.Parameters = append(operation.Parameters, p)
+				continue
+			}
+			p := OpenAPIParameter{
 				Name:     param,
 				In:       "path",
 				Required: true,
 				Schema: &OpenAPISchema{
 					Type: "string",
 				},
-			})
+			}
+			if strings.Contains(node.pattern, "/*"+param) {
+				p.Description = "Catch-all parameter matching the rest of the path, including slashes"
+			}
+			operation.Parameters = append(operation.Parameters, p)
+		}
+		for _, param := range typed {
+			if param.In != "path" {
+				operation.Parameters = append(operation.Parameters, param)
+			}
 		}
 
 		// Add request body if specified
@@ -292,7 +389,7 @@ func (r *Router) traverseNode(node *node, method string, currentPath string, spe
 		}
 
 		// Add responses
-		if len(metadata.Responses) > 0 {
+		if len(metadata.Responses) > 0 || len(metadata.ResponseContents) > 0 {
 			for code, respType := range metadata.Responses {
 				schema := generateSchema(respType, spec.Components.Schemas)
 				operation.Responses[code] = OpenAPIResponse{
@@ -304,6 +401,21 @@ func (r *Router) traverseNode(node *node, method string, currentPath string, spe
 					},
 				}
 			}
+			for code, contents := range metadata.ResponseContents {
+				resp, ok := operation.Responses[code]
+				if !ok {
+					resp = OpenAPIResponse{
+						Description: getResponseDescription(code),
+						Content:     make(map[string]OpenAPIMediaType),
+					}
+				}
+				for contentType, respType := range contents {
+					resp.Content[contentType] = OpenAPIMediaType{
+						Schema: mediaTypeSchema(respType, spec.Components.Schemas),
+					}
+				}
+				operation.Responses[code] = resp
+			}
 		} else {
 			// Default response
 			operation.Responses["200"] = OpenAPIResponse{
@@ -325,7 +437,7 @@ func (r *Router) traverseNode(node *node, method string, currentPath string, spe
 			pathItem.Patch = operation
 		}
 
-		spec.Paths[node.pattern] = pathItem
+		spec.Paths[path] = pathItem
 	}
 
 	// Traverse children
@@ -334,18 +446,91 @@ func (r *Router) traverseNode(node *node, method string, currentPath string, spe
 	}
 }
 
-// extractPathParameters extracts parameter names from a path pattern.
+// mediaTypeSchema generates the schema for a response body. A nil type or
+// a byte slice is documented as a binary string.
+func mediaTypeSchema(t reflect.Type, schemas map[string]*OpenAPISchema) *OpenAPISchema {
+	if t == nil || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8) {
+		return &OpenAPISchema{Type: "string", Format: "binary"}
+	}
+	return generateSchema(t, schemas)
+}
+
+// structParameters returns the path, query and header parameters declared
+// by the tags of a request struct, including fields of embedded structs.
+// The description and example tags document each parameter.
+func structParameters(t reflect.Type, schemas map[string]*OpenAPISchema) []OpenAPIParameter {
+	if t == nil {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []OpenAPIParameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Tag.Get("path") == "" && field.Tag.Get("query") == "" && field.Tag.Get("header") == "" {
+			params = append(params, structParameters(field.Type, schemas)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		for _, in := range []string{"path", "query", "header"} {
+			name := field.Tag.Get(in)
+			if in == "path" && name == "" {
+				name = field.Tag.Get("param")
+			}
+			if name == "" || name == "-" {
+				continue
+			}
+
+			param := OpenAPIParameter{
+				Name:        name,
+				In:          in,
+				Description: field.Tag.Get("description"),
+				Required:    in == "path" || hasRule(validationTag(field), "required"),
+				Schema:      generateSchema(field.Type, schemas),
+			}
+			if example := field.Tag.Get("example"); example != "" {
+				param.Example = example
+			}
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// extractPathParameters extracts parameter names from a path pattern,
+// including catch-all wildcards such as *filepath.
 func extractPathParameters(pattern string) []string {
 	var params []string
 	parts := strings.Split(pattern, "/")
 	for _, part := range parts {
-		if strings.HasPrefix(part, ":") {
+		if strings.HasPrefix(part, ":") || (strings.HasPrefix(part, "*") && len(part) > 1) {
 			params = append(params, part[1:])
 		}
 	}
 	return params
 }
 
+// openAPIPath converts a route pattern to an OpenAPI path template, so
+// /users/:id becomes /users/{id} and /static/*filepath becomes
+// /static/{filepath}.
+func openAPIPath(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || (strings.HasPrefix(part, "*") && len(part) > 1) {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
 // generateSchema generates an OpenAPI schema from a Go type.
 func generateSchema(t reflect.Type, schemas map[string]*OpenAPISchema) *OpenAPISchema {
 	if t == nil {
@@ -424,10 +609,8 @@ func generateSchema(t reflect.Type, schemas map[string]*OpenAPISchema) *OpenAPIS
 			}
 
 			// Check if field is required
-			if validateTag := field.Tag.Get("validate"); validateTag != "" {
-				if strings.Contains(validateTag, "required") {
-					required = append(required, fieldName)
-				}
+			if hasRule(validationTag(field), "required") {
+				required = append(required, fieldName)
 			}
 
 			schema.Properties[fieldName] = fieldSchema
@@ -2,6 +2,7 @@ package ginji
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -106,6 +107,7 @@ func TestSchemaGeneration(t *testing.T) {
 		Age      int     `json:"age" description:"Age field" example:"30"`
 		Email    string  `json:"email" validate:"email" description:"Email field"`
 		Optional *string `json:"optional,omitempty" description:"Optional field"`
+		Phone    string  `json:"phone" validate:"required_without=Email"`
 	}
 
 	schemas := make(map[string]*OpenAPISchema)
@@ -223,6 +225,46 @@ func TestRouteMetadata(t *testing.T) {
 	}
 }
 
+func TestGroupMetadataInheritance(t *testing.T) {
+	app := New()
+
+	admin := app.Group("/admin").Tags("admin").Security("bearerAuth")
+	users := admin.Group("/users").Tags("users")
+
+	users.Get("/", func(c *Context) error { return nil })
+	users.Post("/", func(c *Context) error { return nil }).
+		Tags("users", "write").
+		Security("apiKey", "users:write")
+	app.Get("/health", func(c *Context) error { return nil })
+
+	meta := app.router.getRouteMetadata("GET-/admin/users/")
+	if !reflect.DeepEqual(meta.Tags, []string{"admin", "users"}) {
+		t.Errorf("Expected inherited tags [admin users], got %v", meta.Tags)
+	}
+	if !reflect.DeepEqual(meta.Security, []map[string][]string{{"bearerAuth": {}}}) {
+		t.Errorf("Expected inherited bearerAuth security, got %v", meta.Security)
+	}
+
+	meta = app.router.getRouteMetadata("POST-/admin/users/")
+	if !reflect.DeepEqual(meta.Tags, []string{"admin", "users", "write"}) {
+		t.Errorf("Expected merged tags [admin users write], got %v", meta.Tags)
+	}
+	if len(meta.Security) != 2 || meta.Security[1]["apiKey"][0] != "users:write" {
+		t.Errorf("Expected route security appended to group security, got %v", meta.Security)
+	}
+
+	meta = app.router.getRouteMetadata("GET-/health")
+	if len(meta.Tags) != 0 || len(meta.Security) != 0 {
+		t.Errorf("Expected no inherited metadata outside the group, got %v %v", meta.Tags, meta.Security)
+	}
+
+	spec := app.GenerateOpenAPI(OpenAPIConfig{Title: "Test", Version: "1.0.0"})
+	op := spec.Paths["/admin/users/"].Get
+	if op == nil || len(op.Security) != 1 {
+		t.Errorf("Expected security on generated operation, got %+v", op)
+	}
+}
+
 func TestExtractPathParameters(t *testing.T) {
 	tests := []struct {
 		pattern  string
@@ -230,7 +272,8 @@ func TestExtractPathParameters(t *testing.T) {
 	}{
 		{"/users/:id", []string{"id"}},
 		{"/users/:id/posts/:postId", []string{"id", "postId"}},
-		{"/static/*filepath", []string{}},
+		{"/static/*filepath", []string{"filepath"}},
+		{"/files/:bucket/*key", []string{"bucket", "key"}},
 		{"/api/v1/users/:userId/comments/:commentId", []string{"userId", "commentId"}},
 		{"/users", []string{}},
 	}
@@ -249,3 +292,179 @@ func TestExtractPathParameters(t *testing.T) {
 		}
 	}
 }
+
+func TestOperationIDGeneration(t *testing.T) {
+	app := New()
+	noop := func(c *Context) error { return nil }
+
+	app.Get("/", noop)
+	app.Get("/users", noop)
+	app.Post("/users", noop)
+	app.Get("/users/:id", noop)
+	app.Get("/user_profiles", noop)
+	app.Get("/user-profiles", noop) // collides with /user_profiles
+	app.Delete("/users/:id", noop).OperationID("removeUser")
+	app.Get("/api/user-profiles/*path", noop)
+
+	spec := app.GenerateOpenAPI(OpenAPIConfig{Title: "Test", Version: "1.0.0"})
+
+	expected := map[string]string{
+		"GET /":                         "getRoot",
+		"GET /users":                    "getUsers",
+		"POST /users":                   "postUsers",
+		"GET /users/{id}":               "getUsersId",
+		"GET /user-profiles":            "getUserProfiles",
+		"GET /user_profiles":            "getUserProfiles2",
+		"DELETE /users/{id}":            "removeUser",
+		"GET /api/user-profiles/{path}": "getApiUserProfilesPath",
+	}
+
+	seen := make(map[string]bool)
+	for key, want := range expected {
+		method, path, _ := strings.Cut(key, " ")
+		item := spec.Paths[path]
+		op := item.Get
+		switch method {
+		case "POST":
+			op = item.Post
+		case "DELETE":
+			op = item.Delete
+		}
+		if op == nil {
+			t.Fatalf("Expected operation for %s", key)
+		}
+		if op.OperationID != want {
+			t.Errorf("%s: expected operationId %s, got %s", key, want, op.OperationID)
+		}
+		if seen[op.OperationID] {
+			t.Errorf("Duplicate operationId %s", op.OperationID)
+		}
+		seen[op.OperationID] = true
+	}
+}
+
+func TestOpenAPIParameterDescriptions(t *testing.T) {
+	type ListItemsRequest struct {
+		OrgID   string `path:"org" description:"Organization identifier" example:"acme"`
+		Page    int    `query:"page" description:"Page number" example:"2"`
+		Search  string `query:"q" validate:"required"`
+		TraceID string `header:"X-Trace-ID" description:"Request trace ID" validate:"required_with=Search"`
+		Ignored string `query:"-"`
+	}
+
+	app := New()
+	app.Typed().Get("/orgs/:org/items", func(c *Context, req ListItemsRequest) (string, error) {
+		return "", nil
+	})
+
+	spec := app.GenerateOpenAPI(OpenAPIConfig{Title: "Test", Version: "1.0.0"})
+	op := spec.Paths["/orgs/{org}/items"].Get
+	if op == nil {
+		t.Fatal("Expected GET operation")
+	}
+
+	params := make(map[string]OpenAPIParameter)
+	for _, p := range op.Parameters {
+		params[p.In+":"+p.Name] = p
+	}
+	if len(op.Parameters) != 4 {
+		t.Fatalf("Expected 4 parameters, got %d: %+v", len(op.Parameters), op.Parameters)
+	}
+
+	org := params["path:org"]
+	if org.Description != "Organization identifier" || org.Example != "acme" || !org.Required {
+		t.Errorf("Expected documented required path parameter, got %+v", org)
+	}
+	page := params["query:page"]
+	if page.Description != "Page number" || page.Example != "2" || page.Required {
+		t.Errorf("Expected documented optional query parameter, got %+v", page)
+	}
+	if page.Schema == nil || page.Schema.Type != "integer" {
+		t.Errorf("Expected integer schema for page, got %+v", page.Schema)
+	}
+	if !params["query:q"].Required {
+		t.Error("Expected validate:\"required\" query parameter to be required")
+	}
+	if params["header:X-Trace-ID"].Required {
+		t.Error("Expected conditionally required header parameter to be optional")
+	}
+	if params["header:X-Trace-ID"].Description != "Request trace ID" {
+		t.Errorf("Expected header parameter description, got %+v", params["header:X-Trace-ID"])
+	}
+}
+
+func TestResponseContent(t *testing.T) {
+	type Report struct {
+		ID string `json:"id"`
+	}
+
+	app := New()
+	app.Get("/reports/:id", func(c *Context) error { return nil }).
+		Response(200, Report{}).
+		ResponseContent(200, "application/xml", Report{}).
+		ResponseContent(200, "application/pdf", nil).
+		ResponseContent(404, "text/plain", "")
+
+	spec := app.GenerateOpenAPI(OpenAPIConfig{Title: "Test", Version: "1.0.0"})
+	op := spec.Paths["/reports/{id}"].Get
+
+	ok := op.Responses["200"]
+	if len(ok.Content) != 3 {
+		t.Fatalf("Expected 3 media types for 200, got %v", ok.Content)
+	}
+	if ok.Content["application/json"].Schema == nil || ok.Content["application/xml"].Schema == nil {
+		t.Error("Expected JSON and XML schemas for 200")
+	}
+	pdf := ok.Content["application/pdf"].Schema
+	if pdf == nil || pdf.Type != "string" || pdf.Format != "binary" {
+		t.Errorf("Expected binary schema for PDF, got %+v", pdf)
+	}
+
+	notFound := op.Responses["404"]
+	if notFound.Description == "" || notFound.Content["text/plain"].Schema.Type != "string" {
+		t.Errorf("Expected text/plain 404 response, got %+v", notFound)
+	}
+}
+
+func TestOpenAPIWildcardPath(t *testing.T) {
+	app := New()
+	app.Get("/static/*filepath", func(c *Context) error { return nil })
+
+	spec := app.GenerateOpenAPI(OpenAPIConfig{Title: "Test", Version: "1.0.0"})
+	if _, ok := spec.Paths["/static/*filepath"]; ok {
+		t.Error("Expected wildcard to be converted to a path template")
+	}
+	item, ok := spec.Paths["/static/{filepath}"]
+	if !ok || item.Get == nil {
+		t.Fatalf("Expected /static/{filepath} in spec, got %v", spec.Paths)
+	}
+
+	params := item.Get.Parameters
+	if len(params) != 1 || params[0].Name != "filepath" || params[0].In != "path" || !params[0].Required {
+		t.Fatalf("Expected required filepath path parameter, got %+v", params)
+	}
+	if params[0].Description == "" {
+		t.Error("Expected catch-all note in parameter description")
+	}
+}
+
+func TestOpenAPIPathTemplates(t *testing.T) {
+	app := New()
+	app.Get("/users/:id/posts/:postId", func(c *Context) error { return nil })
+
+	spec := app.GenerateOpenAPI(OpenAPIConfig{Title: "Test", Version: "1.0.0"})
+	if _, ok := spec.Paths["/users/:id/posts/:postId"]; ok {
+		t.Error("Expected colon path to be converted")
+	}
+	item, ok := spec.Paths["/users/{id}/posts/{postId}"]
+	if !ok || item.Get == nil {
+		t.Fatalf("Expected /users/{id}/posts/{postId} in spec, got %v", spec.Paths)
+	}
+
+	// Parameter names must match the template placeholders
+	for i, name := range []string{"id", "postId"} {
+		if item.Get.Parameters[i].Name != name {
+			t.Errorf("Expected parameter %s, got %s", name, item.Get.Parameters[i].Name)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package ginji
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Page is a typed handler response for one page of a list. It is written
+// as JSON with X-Total-Count and Link headers set by Paginate.
+type Page[T any] struct {
+	Items   []T `json:"items"`
+	Total   int `json:"total"`
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+}
+
+// Respond implements Responder.
+func (p Page[T]) Respond(c *Context) error {
+	if p.Items == nil {
+		p.Items = []T{}
+	}
+	c.Paginate(p.Total, p.Page, p.PerPage)
+	return c.JSON(StatusOK, p)
+}
+
+// Paginate sets the X-Total-Count header and an RFC 5988 Link header with
+// first, prev, next and last relations. Links reuse the current request URL
+// with the page and per_page query parameters replaced.
+func (c *Context) Paginate(total, page, perPage int) {
+	c.SetHeader("X-Total-Count", strconv.Itoa(total))
+	if perPage <= 0 {
+		return
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	last := (total + perPage - 1) / perPage
+	if last < 1 {
+		last = 1
+	}
+
+	links := []string{c.pageLink(1, perPage, "first")}
+	if page > 1 {
+		links = append(links, c.pageLink(min(page-1, last), perPage, "prev"))
+	}
+	if page < last {
+		links = append(links, c.pageLink(page+1, perPage, "next"))
+	}
+	links = append(links, c.pageLink(last, perPage, "last"))
+	c.SetHeader("Link", strings.Join(links, ", "))
+}
+
+// pageLink formats a single Link header entry for the given page.
+func (c *Context) pageLink(page, perPage int, rel string) string {
+	u := url.URL{Path: c.Req.URL.Path}
+	query := c.Req.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(perPage))
+	u.RawQuery = query.Encode()
+	return "<" + u.String() + `>; rel="` + rel + `"`
+}
@@ -0,0 +1,82 @@
+package ginji
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	app := New()
+	app.Get("/items", func(c *Context) error {
+		c.Paginate(95, 3, 20)
+		return c.Text(StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/items?page=3&sort=name", nil))
+
+	if got := w.Header().Get("X-Total-Count"); got != "95" {
+		t.Errorf("Expected X-Total-Count 95, got %s", got)
+	}
+	expected := `</items?page=1&per_page=20&sort=name>; rel="first", ` +
+		`</items?page=2&per_page=20&sort=name>; rel="prev", ` +
+		`</items?page=4&per_page=20&sort=name>; rel="next", ` +
+		`</items?page=5&per_page=20&sort=name>; rel="last"`
+	if got := w.Header().Get("Link"); got != expected {
+		t.Errorf("Expected Link %s, got %s", expected, got)
+	}
+}
+
+func TestPaginateEdges(t *testing.T) {
+	tests := []struct {
+		name                 string
+		total, page, perPage int
+		expected             string
+	}{
+		{"first page", 30, 1, 10, `</p?page=1&per_page=10>; rel="first", </p?page=2&per_page=10>; rel="next", </p?page=3&per_page=10>; rel="last"`},
+		{"last page", 30, 3, 10, `</p?page=1&per_page=10>; rel="first", </p?page=2&per_page=10>; rel="prev", </p?page=3&per_page=10>; rel="last"`},
+		{"empty", 0, 1, 10, `</p?page=1&per_page=10>; rel="first", </p?page=1&per_page=10>; rel="last"`},
+		{"no page size", 30, 1, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := New()
+			app.Get("/p", func(c *Context) error {
+				c.Paginate(tt.total, tt.page, tt.perPage)
+				return nil
+			})
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest("GET", "/p", nil))
+			if got := w.Header().Get("Link"); got != tt.expected {
+				t.Errorf("Expected Link %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTypedPageResponse(t *testing.T) {
+	app := New()
+	app.Typed().Get("/users", func(c *Context, _ EmptyRequest) (Page[string], error) {
+		return Page[string]{Items: []string{"ann", "bob"}, Total: 4, Page: 1, PerPage: 2}, nil
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+
+	if w.Code != StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if w.Header().Get("X-Total-Count") != "4" || w.Header().Get("Link") == "" {
+		t.Errorf("Expected pagination headers, got %v", w.Header())
+	}
+
+	var body Page[string]
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if len(body.Items) != 2 || body.Total != 4 || body.PerPage != 2 {
+		t.Errorf("Unexpected page body: %+v", body)
+	}
+}
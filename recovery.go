@@ -1,36 +1,25 @@
 package ginji
 
 import (
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"runtime"
+	"runtime/debug"
 )
 
 // Recovery returns a middleware that recovers from any panics and writes a 500 if there was one.
+// The panic is logged with its stack and the request ID, method, route and path.
 func Recovery() Middleware {
 	return func(c *Context) error {
 		defer func() {
 			if err := recover(); err != nil {
-				message := fmt.Sprintf("%s", err)
-				log.Printf("%s\n\n", trace(message))
+				stack := string(debug.Stack())
+				c.logger().Error("Recovered from panic", append(c.requestAttrs(), slog.Any("panic", err), slog.String("stack", stack))...)
+				if c.engine != nil {
+					c.engine.executeOnPanic(c, err, stack)
+				}
 				_ = c.Text(http.StatusInternalServerError, "Internal Server Error")
 			}
 		}()
 		return c.Next()
 	}
 }
-
-// trace returns a stack trace for the panic.
-func trace(message string) string {
-	var pcs [32]uintptr
-	n := runtime.Callers(3, pcs[:]) // skip first 3 frames
-	var str string
-	str += message + "\nTraceback:"
-	for _, pc := range pcs[:n] {
-		fn := runtime.FuncForPC(pc)
-		file, line := fn.FileLine(pc)
-		str += fmt.Sprintf("\n\t%s:%d", file, line)
-	}
-	return str
-}
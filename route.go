@@ -1,8 +1,10 @@
 package ginji
 
 import (
+	"fmt"
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/ginjigo/schema"
 )
@@ -10,6 +12,7 @@ import (
 // Route represents a chainable route for adding metadata.
 type Route struct {
 	engine       *Engine
+	group        *RouterGroup
 	method       string
 	pattern      string
 	handler      Handler
@@ -23,12 +26,20 @@ type Route struct {
 // RouteMetadata stores metadata about a route for documentation and validation.
 type RouteMetadata struct {
 	RequestType reflect.Type // Renamed from Request to RequestType
+	ParamsType  reflect.Type // typed request whose path/query/header fields become parameters
 	Responses   map[string]reflect.Type
-	Summary     string
-	Description string // Added Description field
-	Tags        []string
-	OperationID string // Added OperationID field
-	Deprecated  bool
+	// ResponseContents maps status codes to media types and their body types,
+	// for statuses that declare content types other than JSON.
+	ResponseContents map[string]map[string]reflect.Type
+	Summary          string
+	Description      string // Added Description field
+	Tags             []string
+	OperationID      string // Added OperationID field
+	Deprecated       bool
+	Security         []map[string][]string
+	Timeout          time.Duration // deadline applied to the request context, 0 for none
+
+	groupTags []string // tags inherited from the route's groups
 }
 
 // Summary sets the route summary.
@@ -43,9 +54,19 @@ func (r *Route) Description(description string) *Route {
 	return r
 }
 
-// Tags sets the route tags.
+// Tags sets the route tags. Tags inherited from the route's groups are kept.
 func (r *Route) Tags(tags ...string) *Route {
-	r.meta.Tags = tags
+	r.meta.Tags = mergeTags(r.meta.groupTags, tags)
+	return r
+}
+
+// Security adds an OpenAPI security requirement to the route, in addition
+// to any inherited from its groups.
+func (r *Route) Security(scheme string, scopes ...string) *Route {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	r.meta.Security = append(r.meta.Security, map[string][]string{scheme: scopes})
 	return r
 }
 
@@ -71,15 +92,47 @@ func (r *Route) Response(code int, example interface{}) *Route {
 	return r
 }
 
+// ResponseContent declares a media type for a status code. Call it several
+// times with the same code to document content-negotiated or binary
+// responses. A nil or []byte schema documents a binary body.
+func (r *Route) ResponseContent(code int, contentType string, schema any) *Route {
+	if r.meta.ResponseContents == nil {
+		r.meta.ResponseContents = make(map[string]map[string]reflect.Type)
+	}
+	codeStr := strconv.Itoa(code)
+	if r.meta.ResponseContents[codeStr] == nil {
+		r.meta.ResponseContents[codeStr] = make(map[string]reflect.Type)
+	}
+	r.meta.ResponseContents[codeStr][contentType] = reflect.TypeOf(schema)
+	return r
+}
+
+// Timeout sets a deadline for the route. The request context passed to
+// route middleware and the handler is cancelled once it expires.
+func (r *Route) Timeout(d time.Duration) *Route {
+	r.meta.Timeout = d
+	return r
+}
+
+// Name names the route so that its URL can be built with Engine.URL and
+// Context.RedirectToRoute instead of repeating the pattern.
+func (r *Route) Name(name string) *Route {
+	r.engine.router.setRouteName(name, r.pattern)
+	return r
+}
+
 // Deprecated marks the route as deprecated.
 func (r *Route) Deprecated() *Route {
 	r.meta.Deprecated = true
 	return r
 }
 
-// Middlewares adds middleware to this specific route.
+// Middlewares adds middleware to this specific route. It runs after the
+// global and group middleware, just before the handler.
 func (r *Route) Middlewares(middlewares ...Middleware) *Route {
-	r.middlewares = append(r.middlewares, middlewares...)
+	where := fmt.Sprintf("Middlewares of %s %s", r.method, r.pattern)
+	r.middlewares = append(r.middlewares, r.engine.nonNilMiddlewares(where, middlewares)...)
+	r.engine.router.setRouteMiddleware(r.key(), r.middlewares)
 	return r
 }
 
@@ -103,17 +156,32 @@ func (r *Route) Params(s *schema.Schema) *Route {
 
 // build finalizes the route and adds it to the router.
 func (r *Route) build() {
+	if r.handler == nil {
+		r.engine.nilRegistration("nil handler for %s %s", r.method, r.pattern)
+		return
+	}
+
 	// Add route to router
 	r.engine.router.addRoute(r.method, r.pattern, r.handler)
 
 	// Use consistent key for both metadata and middleware
-	key := r.method + "-" + r.pattern
+	key := r.key()
 
 	// Store route-level middleware
 	if len(r.middlewares) > 0 {
 		r.engine.router.setRouteMiddleware(key, r.middlewares)
 	}
 
+	// Remember the group for scoped error handling
+	if r.group != nil {
+		r.engine.router.setRouteGroup(key, r.group)
+	}
+
 	// Set metadata
 	r.engine.router.setRouteMetadata(key, r.meta)
 }
+
+// key returns the key the router stores the route's data under.
+func (r *Route) key() string {
+	return r.method + "-" + r.pattern
+}
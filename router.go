@@ -1,70 +1,165 @@
 package ginji
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"reflect"
+	"slices"
 	"strings"
+	"time"
 )
 
-// node represents a node in the routing trie.
+// node represents a node in the routing trie. The trie is compressed:
+// a chain of static segments with no branches is stored as a single edge,
+// so /api/v1/admin/settings costs one comparison per segment but only one
+// node. Wildcard edges always hold exactly one segment.
 type node struct {
-	pattern  string
-	part     string
-	children []*node
-	isWild   bool
+	pattern      string   // route pattern ending at this node, if any
+	patternParts []string // pattern split into segments, for param extraction
+	part         string   // edge label: the segments joined by "/"
+	segments     []string // segments of the edge
+	children     []*node  // static children first, then ":param", then "*catchAll"
+	isWild       bool
 }
 
-// insert inserts a new pattern into the trie.
-func (n *node) insert(pattern string, parts []string, height int) {
-	if len(parts) == height {
-		n.pattern = pattern
-		return
+// newNode returns a node for the edge made of segments.
+func newNode(segments []string) *node {
+	return &node{
+		part:     strings.Join(segments, "/"),
+		segments: segments,
+		isWild:   isWildSegment(segments[0]),
 	}
+}
+
+// isWildSegment reports whether a pattern segment is a ":param" or
+// "*catchAll" wildcard.
+func isWildSegment(segment string) bool {
+	return segment[0] == ':' || segment[0] == '*'
+}
 
-	part := parts[height]
-	child := n.matchChild(part)
-	if child == nil {
-		child = &node{part: part, isWild: part[0] == ':' || part[0] == '*'}
-		n.children = append(n.children, child)
+// rank orders children for matching: static edges win over parameters,
+// which win over catch-alls.
+func (n *node) rank() int {
+	switch {
+	case !n.isWild:
+		return 0
+	case n.part[0] == ':':
+		return 1
 	}
-	child.insert(pattern, parts, height+1)
+	return 2
 }
 
-// search searches for a node matching the parts.
-func (n *node) search(parts []string, height int) *node {
-	if len(parts) == height || strings.HasPrefix(n.part, "*") {
-		if n.pattern == "" {
-			// If we reached the end of parts but no pattern, check if we have a wildcard child
-			// This allows /assets/ to match /assets/*filepath
-			for _, child := range n.children {
-				if child.isWild && strings.HasPrefix(child.part, "*") {
-					return child
-				}
+// insert inserts a new pattern whose remaining segments are parts.
+func (n *node) insert(pattern string, parts []string) {
+	if len(parts) == 0 {
+		n.pattern = pattern
+		n.patternParts = parsePattern(pattern)
+		return
+	}
+
+	first := parts[0]
+	if isWildSegment(first) {
+		for _, child := range n.children {
+			if child.isWild && child.part == first {
+				child.insert(pattern, parts[1:])
+				return
 			}
-			return nil
 		}
-		return n
+		n.addChild(newNode([]string{first})).insert(pattern, parts[1:])
+		return
 	}
 
-	part := parts[height]
-	children := n.matchChildren(part)
-
-	for _, child := range children {
-		result := child.search(parts, height+1)
-		if result != nil {
-			return result
+	for _, child := range n.children {
+		if child.isWild || child.segments[0] != first {
+			continue
+		}
+		// Follow the edge as far as it agrees with parts, splitting it
+		// where the new pattern branches off
+		k := 1
+		for k < len(child.segments) && k < len(parts) && child.segments[k] == parts[k] {
+			k++
 		}
+		if k < len(child.segments) {
+			child.split(k)
+		}
+		child.insert(pattern, parts[k:])
+		return
 	}
 
-	return nil
+	// A new edge takes all static segments up to the next wildcard
+	k := 1
+	for k < len(parts) && !isWildSegment(parts[k]) {
+		k++
+	}
+	n.addChild(newNode(slices.Clone(parts[:k]))).insert(pattern, parts[k:])
+}
+
+// addChild adds child, keeping children ordered by rank, and returns it.
+func (n *node) addChild(child *node) *node {
+	i := len(n.children)
+	for i > 0 && n.children[i-1].rank() > child.rank() {
+		i--
+	}
+	n.children = slices.Insert(n.children, i, child)
+	return child
 }
 
-func (n *node) matchChild(part string) *node {
+// split shortens the edge of n to its first k segments, moving the rest of
+// the edge, the pattern and the children to a new child.
+func (n *node) split(k int) {
+	rest := newNode(n.segments[k:])
+	rest.pattern = n.pattern
+	rest.patternParts = n.patternParts
+	rest.children = n.children
+
+	n.segments = n.segments[:k:k]
+	n.part = strings.Join(n.segments, "/")
+	n.pattern = ""
+	n.patternParts = nil
+	n.children = []*node{rest}
+}
+
+// search returns the node of the route matching parts, whose first
+// height segments already matched the path to n.
+func (n *node) search(parts []string, height int) *node {
+	if len(parts) == height {
+		if n.pattern != "" {
+			return n
+		}
+		// A catch-all also matches an empty rest, so /assets/ matches
+		// /assets/*filepath
+		for _, child := range n.children {
+			if child.isWild && child.part[0] == '*' {
+				return child
+			}
+		}
+		return nil
+	}
+
 	for _, child := range n.children {
-		if child.part == part || child.isWild {
+		switch {
+		case !child.isWild:
+			end := height + len(child.segments)
+			if end > len(parts) || !slices.Equal(parts[height:end], child.segments) {
+				continue
+			}
+			if result := child.search(parts, end); result != nil {
+				return result
+			}
+		case child.part[0] == ':':
+			if result := child.search(parts, height+1); result != nil {
+				return result
+			}
+		default:
+			// Catch-alls are always last in a pattern and take the rest
 			return child
 		}
 	}
+
 	return nil
 }
 
@@ -96,14 +191,56 @@ func (r *Router) getRouteMiddleware(key string) []Middleware {
 	return r.routeMiddleware[key]
 }
 
-func (n *node) matchChildren(part string) []*node {
-	nodes := make([]*node, 0)
-	for _, child := range n.children {
-		if child.part == part || child.isWild {
-			nodes = append(nodes, child)
+// setRouteGroup records the group a route was registered on.
+func (r *Router) setRouteGroup(key string, group *RouterGroup) {
+	r.routeGroups[key] = group
+}
+
+// setRouteName names the route pattern for reverse routing. Routes of
+// different methods may share a name if they share the pattern.
+func (r *Router) setRouteName(name, pattern string) {
+	if existing, ok := r.names[name]; ok && existing != pattern {
+		if mode == DebugMode {
+			panic(fmt.Sprintf("ginji: route name %q already used for %s", name, existing))
 		}
+		log.Printf("Route name %q already used for %s, now naming %s", name, existing, pattern)
 	}
-	return nodes
+	r.names[name] = pattern
+}
+
+// buildURL fills the parameters of the pattern named name from params.
+func (r *Router) buildURL(name string, params map[string]string) (string, error) {
+	pattern, ok := r.names[name]
+	if !ok {
+		return "", fmt.Errorf("ginji: no route named %q", name)
+	}
+
+	parts := parsePattern(pattern)
+	for i, part := range parts {
+		if part[0] != ':' && part[0] != '*' {
+			continue
+		}
+		value, ok := params[part[1:]]
+		if !ok {
+			return "", fmt.Errorf("ginji: missing parameter %q for route %q", part[1:], name)
+		}
+		if part[0] == '*' {
+			// Catch-all values may span several segments
+			segments := strings.Split(strings.TrimPrefix(value, "/"), "/")
+			for j, segment := range segments {
+				segments[j] = url.PathEscape(segment)
+			}
+			parts[i] = strings.Join(segments, "/")
+			continue
+		}
+		parts[i] = url.PathEscape(value)
+	}
+
+	path := "/" + strings.Join(parts, "/")
+	if strings.HasSuffix(pattern, "/") && path != "/" {
+		path += "/"
+	}
+	return path, nil
 }
 
 // Router handles request routing.
@@ -112,6 +249,8 @@ type Router struct {
 	handlers        map[string]Handler
 	metadata        map[string]*RouteMetadata
 	routeMiddleware map[string][]Middleware
+	routeGroups     map[string]*RouterGroup
+	names           map[string]string // route name to pattern, for reverse routing
 }
 
 // newRouter creates a new Router instance.
@@ -121,6 +260,8 @@ func newRouter() *Router {
 		handlers:        make(map[string]Handler),
 		metadata:        make(map[string]*RouteMetadata),
 		routeMiddleware: make(map[string][]Middleware),
+		routeGroups:     make(map[string]*RouterGroup),
+		names:           make(map[string]string),
 	}
 }
 
@@ -137,20 +278,66 @@ func parsePattern(pattern string) []string {
 }
 
 // addRoute adds a route to the router.
+// Registering the same method and pattern twice panics in debug mode and
+// logs a warning otherwise, in which case the later handler wins.
+// Patterns are matched segment by segment: ":name" matches one segment and
+// "*name" matches the rest of the path, so it must be the last segment.
+// A pattern with "*" elsewhere could never match and panics.
 func (r *Router) addRoute(method string, pattern string, handler Handler) {
 	if pattern == "" {
 		pattern = "/"
 	}
 	parts := parsePattern(pattern)
+	for i, part := range parts {
+		if part[0] == '*' && i != len(parts)-1 {
+			panic(fmt.Sprintf("ginji: catch-all %q must be the last segment of pattern %s %s", part, method, pattern))
+		}
+	}
 	key := method + "-" + pattern
+	if _, exists := r.handlers[key]; exists {
+		if mode == DebugMode {
+			panic(fmt.Sprintf("ginji: duplicate route registration for %s %s", method, pattern))
+		}
+		log.Printf("Duplicate route registration for %s %s, overwriting previous handler", method, pattern)
+	}
 	_, ok := r.roots[method]
 	if !ok {
 		r.roots[method] = &node{}
 	}
-	r.roots[method].insert(pattern, parts, 0)
+	r.roots[method].insert(pattern, parts)
 	r.handlers[key] = handler
 }
 
+// nilRegistration reports the registration of a nil handler or middleware.
+// Like a duplicate route, it panics in debug mode so that wiring mistakes
+// such as an unassigned handler variable surface at startup; otherwise it
+// is logged with the engine logger and the registration is ignored.
+func (engine *Engine) nilRegistration(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if mode == DebugMode {
+		panic("ginji: " + msg)
+	}
+	logger := slog.Default()
+	if engine != nil && engine.Logger != nil {
+		logger = engine.Logger
+	}
+	logger.Warn("Ignoring nil registration", slog.String("registration", msg))
+}
+
+// nonNilMiddlewares returns middlewares without nil entries, reporting
+// each with nilRegistration. where names the registration site.
+func (engine *Engine) nonNilMiddlewares(where string, middlewares []Middleware) []Middleware {
+	kept := make([]Middleware, 0, len(middlewares))
+	for i, mw := range middlewares {
+		if mw == nil {
+			engine.nilRegistration("nil middleware at index %d passed to %s", i, where)
+			continue
+		}
+		kept = append(kept, mw)
+	}
+	return kept
+}
+
 // getRoute resolves a route and extracts parameters.
 func (r *Router) getRoute(method string, path string) (*node, map[string]string) {
 	searchParts := parsePattern(path)
@@ -164,8 +351,7 @@ func (r *Router) getRoute(method string, path string) (*node, map[string]string)
 	n := root.search(searchParts, 0)
 
 	if n != nil {
-		parts := parsePattern(n.pattern)
-		for index, part := range parts {
+		for index, part := range n.patternParts {
 			if part[0] == ':' {
 				params[part[1:]] = searchParts[index]
 			}
@@ -206,6 +392,7 @@ func (r *Router) handle(c *Context, engine *Engine) {
 	n, params := r.getRoute(c.Req.Method, c.Req.URL.Path)
 	if n != nil {
 		c.Params = params
+		c.pattern = n.pattern
 
 		// Execute OnRoute hooks
 		if engine != nil {
@@ -217,6 +404,12 @@ func (r *Router) handle(c *Context, engine *Engine) {
 
 		key := c.Req.Method + "-" + n.pattern
 		handler := r.handlers[key]
+		c.group = r.routeGroups[key]
+
+		// Apply the route timeout ahead of route middleware so it covers them
+		if meta, ok := r.metadata[key]; ok && meta.Timeout > 0 {
+			c.handlers = append(c.handlers, routeTimeout(meta.Timeout))
+		}
 
 		// Get route-specific middleware
 		routeMW := r.getRouteMiddleware(key)
@@ -232,9 +425,39 @@ func (r *Router) handle(c *Context, engine *Engine) {
 		// Note: OnResponse hooks are executed in ginji.go ServeHTTP as part of the middleware chain.
 		// The first middleware added wraps c.Next() to execute hooks after all handlers complete.
 
+	} else if engine != nil && engine.noRoute != nil {
+		c.handlers = append(c.handlers, engine.noRoute)
 	} else {
 		c.handlers = append(c.handlers, func(c *Context) error {
 			return c.Text(http.StatusNotFound, "404 NOT FOUND")
 		})
 	}
 }
+
+// routeTimeout returns a handler that runs the rest of the chain with a
+// request context deadline. An earlier deadline already set on the request,
+// e.g. by a timeout middleware, is kept instead of wrapping it again.
+// If the deadline passes before a response is written, it responds with
+// 503 Service Unavailable.
+func routeTimeout(timeout time.Duration) Handler {
+	return func(c *Context) error {
+		deadline := time.Now().Add(timeout)
+		if existing, ok := c.Req.Context().Deadline(); ok && !existing.After(deadline) {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithDeadline(c.Req.Context(), deadline)
+		defer cancel()
+
+		req := c.Req
+		c.Req = req.WithContext(ctx)
+		err := c.Next()
+		c.Req = req
+
+		if ctx.Err() == context.DeadlineExceeded && !c.Written() {
+			c.AbortWithError(http.StatusServiceUnavailable, NewHTTPError(http.StatusServiceUnavailable, "Request timed out"))
+			return nil
+		}
+		return err
+	}
+}
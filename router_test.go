@@ -1,9 +1,17 @@
 package ginji
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestWildcardRoute(t *testing.T) {
@@ -47,3 +55,517 @@ func TestRequestWithWildcard(t *testing.T) {
 		t.Errorf("Expected *, got %s", w.Body.String())
 	}
 }
+
+func TestStaticFS(t *testing.T) {
+	app := New()
+	assets := fstest.MapFS{
+		"css/style.css": {Data: []byte("body{}")},
+		"app.js":        {Data: []byte("console.log(1)")},
+	}
+	app.Group("/public").StaticFS("/assets", assets)
+
+	req := httptest.NewRequest("GET", "/public/assets/css/style.css", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if w.Body.String() != "body{}" {
+		t.Errorf("Expected body{}, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/public/assets/missing.js", nil)
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestDuplicateRouteRegistration(t *testing.T) {
+	originalMode := GetMode()
+	defer SetMode(originalMode)
+
+	handler := func(text string) Handler {
+		return func(c *Context) error { return c.Text(http.StatusOK, text) }
+	}
+
+	// Debug mode panics to surface the mistake early
+	SetMode(DebugMode)
+	app := New()
+	app.Get("/users", handler("first"))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected panic on duplicate route in debug mode")
+			}
+		}()
+		app.Get("/users", handler("second"))
+	}()
+
+	// Other methods and patterns are not duplicates
+	app.Post("/users", handler("create"))
+	app.Get("/users/", handler("slash"))
+
+	// Release mode warns and keeps the later handler
+	SetMode(ReleaseMode)
+	app = New()
+	app.Get("/users", handler("first"))
+	app.Get("/users", handler("second"))
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Body.String() != "second" {
+		t.Errorf("Expected later handler to win, got %s", w.Body.String())
+	}
+}
+
+func TestRoutesAndStartupLog(t *testing.T) {
+	originalMode := GetMode()
+	defer SetMode(originalMode)
+
+	app := New()
+	noop := func(c *Context) error { return nil }
+	app.Post("/users", noop)
+	app.Get("/users", noop)
+	app.Get("/health", noop)
+
+	routes := app.Routes()
+	expected := []RouteInfo{{"GET", "/health"}, {"GET", "/users"}, {"POST", "/users"}}
+	if len(routes) != len(expected) {
+		t.Fatalf("Expected %d routes, got %v", len(expected), routes)
+	}
+	for i, route := range routes {
+		if route != expected[i] {
+			t.Errorf("Expected route %v, got %v", expected[i], route)
+		}
+	}
+
+	var buf bytes.Buffer
+	app.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	SetMode(DebugMode)
+	app.logStartup(":8080")
+	out := buf.String()
+	if !strings.Contains(out, "addr=:8080") || !strings.Contains(out, "routes=3") {
+		t.Errorf("Expected startup summary, got %s", out)
+	}
+	if !strings.Contains(out, "method=POST path=/users") {
+		t.Errorf("Expected route table, got %s", out)
+	}
+
+	buf.Reset()
+	SetMode(ReleaseMode)
+	app.logStartup(":8080")
+	if buf.Len() != 0 {
+		t.Errorf("Expected no startup log in release mode, got %s", buf.String())
+	}
+}
+
+func TestRouteTimeout(t *testing.T) {
+	app := New()
+	app.Get("/slow", func(c *Context) error {
+		select {
+		case <-c.Req.Context().Done():
+			return nil
+		case <-time.After(time.Second):
+			return c.Text(http.StatusOK, "done")
+		}
+	}).Timeout(20 * time.Millisecond)
+
+	var hasDeadline bool
+	app.Get("/fast", func(c *Context) error {
+		_, hasDeadline = c.Req.Context().Deadline()
+		return c.Text(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 after route timeout, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	if w.Code != http.StatusOK || hasDeadline {
+		t.Errorf("Expected route without timeout to have no deadline, got %d, %v", w.Code, hasDeadline)
+	}
+}
+
+func TestRouteTimeoutKeepsEarlierDeadline(t *testing.T) {
+	app := New()
+	var deadline time.Time
+	app.Get("/report", func(c *Context) error {
+		deadline, _ = c.Req.Context().Deadline()
+		return c.Text(http.StatusOK, "ok")
+	}).Timeout(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	outer, _ := ctx.Deadline()
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/report", nil).WithContext(ctx))
+	if !deadline.Equal(outer) {
+		t.Errorf("Expected earlier outer deadline %v to be kept, got %v", outer, deadline)
+	}
+}
+
+func TestNoRoute(t *testing.T) {
+	app := New()
+	app.Get("/api/users", func(c *Context) error {
+		return c.Text(http.StatusOK, "users")
+	})
+
+	// Without a NoRoute handler unmatched requests get the default 404
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/dashboard", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected default 404, got %d", w.Code)
+	}
+
+	dist := fstest.MapFS{"index.html": {Data: []byte("<html>app</html>")}}
+	var middlewareRan bool
+	app.Use(func(c *Context) error {
+		middlewareRan = true
+		return c.Next()
+	})
+	app.NoRoute(func(c *Context) error {
+		if c.Req.Method != http.MethodGet {
+			return c.Text(http.StatusNotFound, "404 NOT FOUND")
+		}
+		http.ServeFileFS(c.Res, c.Req, dist, "index.html")
+		return nil
+	})
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/dashboard/settings", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "<html>app</html>" {
+		t.Errorf("Expected SPA shell, got %d %q", w.Code, w.Body.String())
+	}
+	if !middlewareRan {
+		t.Error("Expected engine middleware to run before NoRoute")
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+	if w.Body.String() != "users" {
+		t.Errorf("Expected matched route to be unaffected, got %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("POST", "/dashboard", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unmatched POST, got %d", w.Code)
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"/api/users", "/api/users"},
+		{"/api//users", "/api/users"},
+		{"/api/./users", "/api/users"},
+		{"/api/v1/../users", "/api/users"},
+		{"/../users", "/users"},
+		{"/api/users/", "/api/users/"},
+		{"//", "/"},
+		{"", "/"},
+	}
+	for _, tt := range tests {
+		if got := cleanPath(tt.in); got != tt.out {
+			t.Errorf("cleanPath(%q): expected %q, got %q", tt.in, tt.out, got)
+		}
+	}
+
+	app := New()
+	handler := func(c *Context) error { return c.Text(http.StatusOK, c.Req.URL.Path) }
+	app.Get("/api/users", handler)
+	app.Post("/api/users", handler)
+
+	// Disabled by default
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/api//users", nil))
+	if w.Code == http.StatusMovedPermanently {
+		t.Error("Expected no redirect when CleanPath is disabled")
+	}
+
+	app.CleanPath = true
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/api/./users?page=2", nil))
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/api/users?page=2" {
+		t.Errorf("Expected redirect to /api/users?page=2, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("POST", "/api//users", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "/api/users" {
+		t.Errorf("Expected POST to be routed to the cleaned path, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected clean path to be served directly, got %d", w.Code)
+	}
+}
+
+func TestNamedRoutesAndRedirectToRoute(t *testing.T) {
+	app := New()
+	users := app.Group("/users")
+	users.Get("/:id", func(c *Context) error { return c.Text(http.StatusOK, "user") }).Name("users.show")
+	users.Put("/:id", func(c *Context) error { return c.Text(http.StatusOK, "updated") }).Name("users.show")
+	app.Get("/files/*path", func(c *Context) error { return nil }).Name("files")
+
+	app.Get("/old/:id", func(c *Context) error {
+		return c.RedirectToRoute(http.StatusMovedPermanently, "users.show", map[string]string{"id": c.Param("id")})
+	})
+	app.Get("/broken", func(c *Context) error {
+		if err := c.RedirectToRoute(http.StatusFound, "missing", nil); err != nil {
+			return c.Text(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+	app.Get("/bad-code", func(c *Context) error {
+		if err := c.Redirect(http.StatusOK, "/"); err != nil {
+			return c.Text(http.StatusInternalServerError, err.Error())
+		}
+		return nil
+	})
+
+	tests := []struct {
+		name     string
+		route    string
+		params   map[string]string
+		expected string
+		wantErr  bool
+	}{
+		{"param", "users.show", map[string]string{"id": "42"}, "/users/42", false},
+		{"escaped", "users.show", map[string]string{"id": "a b/c"}, "/users/a%20b%2Fc", false},
+		{"catch-all", "files", map[string]string{"path": "docs/read me.md"}, "/files/docs/read%20me.md", false},
+		{"missing param", "users.show", nil, "", true},
+		{"unknown name", "missing", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := app.URL(tt.route, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	w := PerformRequest(app, "GET", "/old/7", nil)
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/users/7" {
+		t.Errorf("Expected 301 to /users/7, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = PerformRequest(app, "GET", "/broken", nil)
+	if w.Code != http.StatusInternalServerError || w.Header().Get("Location") != "" {
+		t.Errorf("Expected unknown route name to fail without redirecting, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	if !strings.Contains(w.Body.String(), `no route named "missing"`) {
+		t.Errorf("Expected unknown route error, got %q", w.Body.String())
+	}
+
+	w = PerformRequest(app, "GET", "/bad-code", nil)
+	if w.Code != http.StatusInternalServerError || w.Header().Get("Location") != "" {
+		t.Errorf("Expected non-3xx redirect to fail, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestOverrideRouteMiddleware(t *testing.T) {
+	auth := func(c *Context) error {
+		return c.Text(http.StatusUnauthorized, "unauthorized")
+	}
+	stub := func(c *Context) error {
+		c.Set("user", "test")
+		return c.Next()
+	}
+
+	app := New()
+	app.Group("/admin").Get("/users", func(c *Context) error {
+		return c.Text(http.StatusOK, c.GetString("user"))
+	}).Middlewares(auth)
+
+	if w := PerformRequest(app, "GET", "/admin/users", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected route middleware to run, got %d", w.Code)
+	}
+	if mws := app.RouteMiddleware("GET", "/admin/users"); len(mws) != 1 {
+		t.Fatalf("Expected 1 route middleware, got %d", len(mws))
+	}
+
+	app.OverrideMiddleware("get", "/admin/users", []Middleware{stub})
+	w := PerformRequest(app, "GET", "/admin/users", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "test" {
+		t.Errorf("Expected stubbed middleware to run, got %d %q", w.Code, w.Body.String())
+	}
+
+	app.OverrideMiddleware("GET", "/admin/users", nil)
+	if w := PerformRequest(app, "GET", "/admin/users", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected route without middleware to succeed, got %d", w.Code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for unknown route")
+		}
+	}()
+	app.OverrideMiddleware("GET", "/missing", nil)
+}
+
+func TestRequestSizeLimits(t *testing.T) {
+	app := New()
+	app.MaxPathLength = 16
+	app.MaxQueryParams = 3
+	app.Get("/*path", func(c *Context) error {
+		return c.Text(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		target   string
+		expected int
+	}{
+		{"/short", http.StatusOK},
+		{"/" + strings.Repeat("a", 16), http.StatusRequestHeaderFieldsTooLarge},
+		{"/q?a=1&b=2&c=3", http.StatusOK},
+		{"/q?a=1&b=2&c=3&d=4", http.StatusRequestHeaderFieldsTooLarge},
+		{"/q?" + strings.Repeat("x", 100), http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := PerformRequest(app, "GET", tt.target, nil); w.Code != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.target, tt.expected, w.Code)
+		}
+	}
+
+	if srv := app.newServer(":0"); srv.MaxHeaderBytes != 0 {
+		t.Errorf("Expected net/http default header limit, got %d", srv.MaxHeaderBytes)
+	}
+	app.MaxHeaderBytes = 8 << 10
+	if srv := app.newServer(":0"); srv.MaxHeaderBytes != 8<<10 {
+		t.Errorf("Expected MaxHeaderBytes to reach the server, got %d", srv.MaxHeaderBytes)
+	}
+}
+
+func TestNilHandlerRegistration(t *testing.T) {
+	originalMode := GetMode()
+	defer SetMode(originalMode)
+
+	expectPanic := func(name, contains string, register func()) {
+		t.Helper()
+		defer func() {
+			r := recover()
+			if r == nil {
+				t.Errorf("%s: expected panic in debug mode", name)
+				return
+			}
+			if msg := fmt.Sprint(r); !strings.Contains(msg, contains) {
+				t.Errorf("%s: expected panic naming %q, got %q", name, contains, msg)
+			}
+		}()
+		register()
+	}
+
+	var unassigned Handler
+	SetMode(DebugMode)
+	app := New()
+	expectPanic("route", "GET /users/:id", func() { app.Get("/users/:id", unassigned) })
+	expectPanic("group route", "POST /api/items", func() { app.Group("/api").Post("/items", nil) })
+	expectPanic("Use", "Use", func() { app.Use(Logger(), nil) })
+	expectPanic("group Use", `"/api"`, func() { app.Group("/api").Use(nil) })
+	expectPanic("Pre", "Pre", func() { app.Pre(nil) })
+	expectPanic("UseFor", "UseFor [GET]", func() { app.UseFor([]string{"GET"}, nil) })
+
+	// Release mode logs and skips the nil entries
+	SetMode(ReleaseMode)
+	app = New()
+	app.Get("/users", unassigned)
+	app.Use(nil)
+	app.UseFor([]string{"GET"}, nil)
+	app.Get("/ok", func(c *Context) error { return c.Text(http.StatusOK, "ok") }).Middlewares(nil)
+
+	if w := PerformRequest(app, "GET", "/users", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected unregistered route to 404, got %d", w.Code)
+	}
+	if w := PerformRequest(app, "GET", "/ok", nil); w.Code != http.StatusOK {
+		t.Errorf("Expected nil middleware to be skipped, got %d", w.Code)
+	}
+}
+
+func TestCatchAllMustBeLast(t *testing.T) {
+	app := New()
+	func() {
+		defer func() {
+			r := recover()
+			if r == nil || !strings.Contains(fmt.Sprint(r), "/proxy/*rest/info") {
+				t.Errorf("Expected panic naming the pattern, got %v", r)
+			}
+		}()
+		app.Get("/proxy/*rest/info", func(c *Context) error { return nil })
+	}()
+
+	// A trailing catch-all still captures the rest, across segments
+	app.Get("/proxy/*rest", func(c *Context) error {
+		return c.Text(http.StatusOK, c.Param("rest"))
+	})
+	if w := PerformRequest(app, "GET", "/proxy/a/b/info", nil); w.Body.String() != "a/b/info" {
+		t.Errorf("Expected a/b/info, got %q", w.Body.String())
+	}
+}
+
+func TestCompressedTrieMatching(t *testing.T) {
+	r := newRouter()
+	patterns := []string{
+		"/api/v1/admin/settings",
+		"/api/v1/admin/users",
+		"/api/v1/:resource",
+		"/api/v1/users/:id",
+		"/api/v1/users/me",
+		"/api/v2",
+		"/files/*path",
+	}
+	for _, p := range patterns {
+		r.addRoute("GET", p, func(c *Context) error { return nil })
+	}
+
+	// Registering /api/v2 split the /api/v1/admin/settings edge
+	if root := r.roots["GET"]; len(root.children) != 2 || root.children[0].part != "api" {
+		t.Errorf("Expected root edges to be split at api, got %d children", len(root.children))
+	}
+
+	tests := []struct {
+		path, pattern string
+		params        map[string]string
+	}{
+		{"/api/v1/admin/settings", "/api/v1/admin/settings", map[string]string{}},
+		{"/api/v1/admin", "/api/v1/:resource", map[string]string{"resource": "admin"}},
+		{"/api/v1/users", "/api/v1/:resource", map[string]string{"resource": "users"}},
+		{"/api/v1/users/me", "/api/v1/users/me", map[string]string{}},
+		{"/api/v1/users/42", "/api/v1/users/:id", map[string]string{"id": "42"}},
+		{"/api/v2", "/api/v2", map[string]string{}},
+		{"/files/a/b", "/files/*path", map[string]string{"path": "a/b"}},
+		{"/api/v1/admin/other", "", nil},
+		{"/api", "", nil},
+	}
+	for _, tt := range tests {
+		n, params := r.getRoute("GET", tt.path)
+		if tt.pattern == "" {
+			if n != nil {
+				t.Errorf("Expected no match for %s, got %s", tt.path, n.pattern)
+			}
+			continue
+		}
+		if n == nil || n.pattern != tt.pattern {
+			t.Errorf("Expected %s to match %s, got %v", tt.path, tt.pattern, n)
+			continue
+		}
+		if !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("Expected params %v for %s, got %v", tt.params, tt.path, params)
+		}
+	}
+}
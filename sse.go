@@ -2,7 +2,6 @@ package ginji
 
 import (
 	"fmt"
-	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -26,9 +25,12 @@ type SSEEvent struct {
 // SSEStream represents an SSE stream.
 type SSEStream struct {
 	ctx           *Context
+	mu            sync.Mutex // serializes writes from Send and the keep-alive goroutine
 	lastEventID   string
 	keepAlive     time.Duration
 	keepAliveDone chan struct{}
+	keepAliveExit chan struct{} // closed once the keep-alive goroutine returns
+	stopOnce      sync.Once
 }
 
 // NewSSEStream creates a new SSE stream.
@@ -47,7 +49,12 @@ func NewSSEStream(c *Context) *SSEStream {
 }
 
 // Send sends an SSE event.
+// It returns context.Canceled once the client has disconnected.
 func (s *SSEStream) Send(event SSEEvent) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
 	var sb strings.Builder
 
 	// Write ID
@@ -75,18 +82,24 @@ func (s *SSEStream) Send(event SSEEvent) error {
 	// End event with double newline
 	sb.WriteString("\n")
 
-	// Write to response
-	_, err := s.ctx.Res.Write([]byte(sb.String()))
-	if err != nil {
-		return err
-	}
+	return s.write(sb.String())
+}
 
-	// Flush immediately
-	if flusher, ok := s.ctx.Res.(http.Flusher); ok {
-		flusher.Flush()
-	}
+// write sends raw event stream text to the client and flushes it.
+func (s *SSEStream) write(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ctx.writeChunk([]byte(text))
+}
 
-	return nil
+// SendRetry tells the client how long to wait before reconnecting after
+// the connection drops. Browsers send the last received event ID in the
+// Last-Event-ID header when they reconnect.
+func (s *SSEStream) SendRetry(d time.Duration) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	return s.write(fmt.Sprintf("retry: %d\n\n", d.Milliseconds()))
 }
 
 // SendData is a convenience method to send just data.
@@ -111,48 +124,75 @@ func (s *SSEStream) SendEvent(eventType, data string) error {
 	})
 }
 
+// Done returns a channel that is closed when the client disconnects.
+// Handlers with long-running loops should select on it to stop promptly.
+func (s *SSEStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
 // SetKeepAlive sets the keep-alive interval.
 func (s *SSEStream) SetKeepAlive(d time.Duration) {
 	s.keepAlive = d
 }
 
-// StartKeepAlive starts sending keep-alive comments.
+// StartKeepAlive starts sending keep-alive comments. They stop with
+// StopKeepAlive or, at the latest, when the request ends.
 func (s *SSEStream) StartKeepAlive() {
-	if s.keepAlive <= 0 {
+	if s.keepAlive <= 0 || s.keepAliveExit != nil {
 		return
 	}
 
 	ticker := time.NewTicker(s.keepAlive)
-	go func() {
+	done := s.Done()
+	s.keepAliveExit = make(chan struct{})
+	s.ctx.onRequestEnd(s.StopKeepAlive)
+	safeGo(s.ctx, func() {
+		defer close(s.keepAliveExit)
 		defer ticker.Stop()
 		for {
 			select {
+			case <-done:
+				return
 			case <-ticker.C:
-				// Send keep-alive comment
-				_, _ = s.ctx.Res.Write([]byte(": keep-alive\n\n"))
-				if flusher, ok := s.ctx.Res.(http.Flusher); ok {
-					flusher.Flush()
+				// Send keep-alive comment, stopping once the client is gone
+				if err := s.write(": keep-alive\n\n"); err != nil {
+					return
 				}
 			case <-s.keepAliveDone:
 				return
 			}
 		}
-	}()
+	})
 }
 
-// StopKeepAlive stops the keep-alive goroutine.
+// StopKeepAlive stops the keep-alive goroutine and waits for it to return,
+// so no keep-alive is written afterwards. It may be called more than once.
 func (s *SSEStream) StopKeepAlive() {
-	close(s.keepAliveDone)
+	s.stopOnce.Do(func() { close(s.keepAliveDone) })
+	if s.keepAliveExit != nil {
+		<-s.keepAliveExit
+	}
 }
 
-// LastEventID returns the last sent event ID.
+// LastEventID returns the ID of the last event sent on the stream, or ""
+// before one is sent. The ID a reconnecting client last received is
+// Context.LastEventID.
 func (s *SSEStream) LastEventID() string {
 	return s.lastEventID
 }
 
 // GetLastEventID returns the last event ID from the client (from Last-Event-ID header).
+//
+// Deprecated: Use Context.LastEventID.
 func (s *SSEStream) GetLastEventID() string {
-	return s.ctx.Header("Last-Event-ID")
+	return s.ctx.LastEventID()
+}
+
+// LastEventID returns the Last-Event-ID header sent by a reconnecting
+// EventSource client, or "" on the first connection. A handler resumes the
+// stream after this ID; SSEStream.LastEventID is the last ID it sent.
+func (c *Context) LastEventID() string {
+	return c.Header("Last-Event-ID")
 }
 
 // SSE creates an SSE stream and calls the handler.
@@ -222,10 +262,18 @@ func (b *SSEBroadcaster) ServeSSE(c *Context) {
 	b.Register(client)
 	defer b.Unregister(client)
 
-	// Send events to client
-	for event := range client {
-		if err := stream.Send(event); err != nil {
+	// Send events to client until the channel closes or the client disconnects
+	for {
+		select {
+		case <-stream.Done():
 			return
+		case event, ok := <-client:
+			if !ok {
+				return
+			}
+			if err := stream.Send(event); err != nil {
+				return
+			}
 		}
 	}
 }
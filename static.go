@@ -0,0 +1,112 @@
+package ginji
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FileConfig configures ServeFiles.
+type FileConfig struct {
+	// Index is the file served for a directory request. Defaults to
+	// "index.html".
+	Index string
+
+	// Listing enables listings of directories without an index file.
+	// They are disabled by default so the layout of root is not exposed.
+	Listing bool
+
+	// NotFound handles requests for missing files. Defaults to a
+	// 404 Not Found error.
+	NotFound Handler
+
+	// Forbidden handles requests for directories that have no index file
+	// when Listing is disabled, and for files that cannot be read.
+	// Defaults to a 403 Forbidden error.
+	Forbidden Handler
+}
+
+// ServeFiles registers a route that serves the files under root. Unlike
+// Static, it does not list directories unless config.Listing is set:
+//
+//	app.ServeFiles("/assets", "./public", ginji.FileConfig{
+//		NotFound: func(c *ginji.Context) error {
+//			return c.Text(http.StatusNotFound, "no such asset")
+//		},
+//	})
+func (group *RouterGroup) ServeFiles(prefix, root string, config FileConfig) {
+	if config.Index == "" {
+		config.Index = "index.html"
+	}
+	if config.NotFound == nil {
+		config.NotFound = func(c *Context) error {
+			c.AbortWithError(http.StatusNotFound, NewHTTPError(http.StatusNotFound))
+			return nil
+		}
+	}
+	if config.Forbidden == nil {
+		config.Forbidden = func(c *Context) error {
+			c.AbortWithError(http.StatusForbidden, NewHTTPError(http.StatusForbidden))
+			return nil
+		}
+	}
+
+	dir := http.Dir(root)
+	lister := http.StripPrefix(group.prefix+prefix, http.FileServer(dir))
+	handler := func(c *Context) error {
+		name := path.Clean("/" + c.Param("filepath"))
+		f, err := dir.Open(name)
+		if err != nil {
+			return serveFileError(c, err, config)
+		}
+		defer func() { _ = f.Close() }()
+
+		stat, err := f.Stat()
+		if err != nil {
+			return serveFileError(c, err, config)
+		}
+		if !stat.IsDir() {
+			http.ServeContent(c.Res, c.Req, stat.Name(), stat.ModTime(), f)
+			return nil
+		}
+
+		// Redirect to the canonical directory path so relative links in
+		// the index resolve, as http.FileServer does
+		if !strings.HasSuffix(c.Req.URL.Path, "/") {
+			target := c.Req.URL.Path + "/"
+			if c.Req.URL.RawQuery != "" {
+				target += "?" + c.Req.URL.RawQuery
+			}
+			return c.Redirect(http.StatusMovedPermanently, target)
+		}
+
+		index, err := dir.Open(path.Join(name, config.Index))
+		if err == nil {
+			defer func() { _ = index.Close() }()
+			if indexStat, err := index.Stat(); err == nil && !indexStat.IsDir() {
+				http.ServeContent(c.Res, c.Req, indexStat.Name(), indexStat.ModTime(), index)
+				return nil
+			}
+		}
+
+		if !config.Listing {
+			return config.Forbidden(c)
+		}
+		lister.ServeHTTP(c.Res, c.Req)
+		return nil
+	}
+	group.addRoute("GET", prefix+"/*filepath", handler)
+}
+
+// serveFileError responds to a failure to open or stat a file.
+func serveFileError(c *Context, err error, config FileConfig) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return config.NotFound(c)
+	case errors.Is(err, fs.ErrPermission):
+		return config.Forbidden(c)
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package ginji
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeFiles(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app.js", "console.log(1)")
+	write("docs/index.html", "<h1>docs</h1>")
+	write("private/secret.txt", "secret")
+
+	get := func(app *Engine, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	app := New()
+	app.ServeFiles("/static", root, FileConfig{})
+
+	tests := []struct {
+		name   string
+		target string
+		code   int
+		body   string
+	}{
+		{"file", "/static/app.js", http.StatusOK, "console.log(1)"},
+		{"index", "/static/docs/", http.StatusOK, "<h1>docs</h1>"},
+		{"no listing", "/static/private/", http.StatusForbidden, ""},
+		{"no root listing", "/static/", http.StatusForbidden, ""},
+		{"missing", "/static/missing.js", http.StatusNotFound, ""},
+		{"traversal", "/static/../../etc/passwd", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(app, tt.target)
+			if w.Code != tt.code {
+				t.Fatalf("Expected %d, got %d", tt.code, w.Code)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), "secret.txt") {
+				t.Error("Expected directory contents not to be listed")
+			}
+		})
+	}
+
+	w := get(app, "/static/docs?v=1")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/static/docs/?v=1" {
+		t.Errorf("Expected redirect to /static/docs/?v=1, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	// Listings and custom error handlers
+	custom := New()
+	custom.ServeFiles("/files", root, FileConfig{
+		Listing: true,
+		NotFound: func(c *Context) error {
+			return c.Text(http.StatusNotFound, "no such file")
+		},
+	})
+	if w := get(custom, "/files/private/"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "secret.txt") {
+		t.Errorf("Expected listing of private/, got %d %q", w.Code, w.Body.String())
+	}
+	if w := get(custom, "/files/missing"); w.Code != http.StatusNotFound || w.Body.String() != "no such file" {
+		t.Errorf("Expected custom 404, got %d %q", w.Code, w.Body.String())
+	}
+
+	forbidden := New()
+	forbidden.ServeFiles("/files", root, FileConfig{
+		Index: "default.htm",
+		Forbidden: func(c *Context) error {
+			return c.Text(http.StatusForbidden, "no listing")
+		},
+	})
+	if w := get(forbidden, "/files/docs/"); w.Code != http.StatusForbidden || w.Body.String() != "no listing" {
+		t.Errorf("Expected custom 403 without the configured index, got %d %q", w.Code, w.Body.String())
+	}
+}
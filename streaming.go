@@ -1,15 +1,20 @@
 package ginji
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log" // Added log import
+	"log/slog"
+	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 )
 
 // Stream sends a streaming response from an io.Reader.
@@ -21,11 +26,112 @@ func (c *Context) Stream(contentType string, reader io.Reader) error {
 	// Set chunked transfer encoding
 	c.SetHeader("Transfer-Encoding", "chunked")
 
-	// Copy from reader to response
-	_, err := io.Copy(c.Res, reader)
+	// Copy from reader to response, stopping if the client goes away
+	if err := copyWithContext(c, c.Res, reader); err != nil {
+		return c.streamError(err)
+	}
+	return nil
+}
+
+// SetTrailer sets a trailer, a header sent after the response body, e.g.
+// a checksum of a streamed body or a final status. Called before the body
+// is written, it also declares the trailer in the Trailer header, which
+// some clients require; the value may be replaced until the handler
+// returns:
+//
+//	c.SetTrailer("Grpc-Status", "0")
+//	hash := sha256.New()
+//	if err := c.Stream("application/grpc-web", io.TeeReader(body, hash)); err != nil {
+//		return err
+//	}
+//	c.SetTrailer("Digest", "sha-256="+base64.StdEncoding.EncodeToString(hash.Sum(nil)))
+//
+// Trailers are sent once the body is complete. Over HTTP/1.1 they need a
+// chunked body, so they are dropped for responses with a Content-Length,
+// such as those of File and Data.
+func (c *Context) SetTrailer(key, value string) {
+	key = http.CanonicalHeaderKey(key)
+	header := c.Res.Header()
+	if c.writer == nil || !c.writer.wroteHeader {
+		mergeHeaderValues(header, "Trailer", key)
+	}
+	header.Set(http.TrailerPrefix+key, value)
+}
+
+// flush sends buffered data written to w to the client. Writers that
+// cannot flush are left to send data when the handler returns.
+func flush(w io.Writer) error {
+	rw, ok := w.(http.ResponseWriter)
+	if !ok {
+		return nil
+	}
+	if err := http.NewResponseController(rw).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// writeChunk writes data to the response and flushes it.
+func (c *Context) writeChunk(data []byte) error {
+	if _, err := c.Res.Write(data); err != nil {
+		return c.streamError(err)
+	}
+	if err := flush(c.Res); err != nil {
+		return c.streamError(err)
+	}
+	return nil
+}
+
+// streamError logs a failed stream write and returns err. A client that
+// disconnected mid-stream is expected and only logged at debug level.
+func (c *Context) streamError(err error) error {
+	attrs := []any{slog.String("path", c.Req.URL.Path), slog.String("error", err.Error())}
+	if isClientDisconnect(err) {
+		c.logger().Debug("Client disconnected during stream", attrs...)
+	} else {
+		c.logger().Error("Stream write failed", attrs...)
+	}
 	return err
 }
 
+// isClientDisconnect reports whether err means the client went away.
+func isClientDisconnect(err error) bool {
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, http.ErrHandlerTimeout)
+}
+
+// copyWithContext copies from src to dst in chunks, returning ctx.Err()
+// as soon as the context is done so that disconnected clients don't keep
+// the copy loop alive.
+func copyWithContext(ctx context.Context, dst io.Writer, src io.Reader) error {
+	buf := make([]byte, 32*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return err
+			}
+			if err := flush(dst); err != nil {
+				return err
+			}
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
 // File sends a file with proper headers.
 func (c *Context) File(filepath string) error {
 	// Validate file path to prevent directory traversal
@@ -168,6 +274,106 @@ func (c *Context) SaveUploadedFile(fileHeader *multipart.FileHeader, dst string)
 	return err
 }
 
+// UploadConfig defines the guards applied by SaveFormFile.
+type UploadConfig struct {
+	// MaxSize is the maximum allowed file size in bytes.
+	// Default: 32MB
+	MaxSize int64
+
+	// AllowedTypes is an allowlist of MIME types, e.g. "image/png" or "image/*".
+	// The type is sniffed from the file content, not taken from the request.
+	// If empty, any type is accepted.
+	AllowedTypes []string
+}
+
+// uploadFormOverhead is the room SaveFormFile leaves on top of
+// UploadConfig.MaxSize for multipart boundaries, part headers and other
+// form fields.
+const uploadFormOverhead = 64 << 10 // 64 KB
+
+// SaveFormFile saves the uploaded file for the given form field into dstDir
+// under a generated unique name and returns the final path.
+// It rejects files larger than cfg.MaxSize with 413 and files whose sniffed
+// content type is not in cfg.AllowedTypes with 415. Unless the form was
+// already parsed, reading the request body stops once it exceeds MaxSize
+// plus a small allowance for the rest of the form, so an oversized upload
+// is rejected without being received in full.
+func (c *Context) SaveFormFile(field, dstDir string, cfg UploadConfig) (string, error) {
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 32 << 20 // 32 MB
+	}
+	if c.Req.MultipartForm == nil && c.Req.Body != nil {
+		c.Req.Body = http.MaxBytesReader(c.Res, c.Req.Body, cfg.MaxSize+uploadFormOverhead)
+	}
+
+	fileHeader, err := c.FormFile(field)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return "", NewHTTPError(http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("request body too large (max %d bytes)", maxErr.Limit))
+		}
+		return "", NewHTTPError(http.StatusBadRequest, fmt.Sprintf("missing file field '%s'", field))
+	}
+
+	if fileHeader.Size > cfg.MaxSize {
+		return "", NewHTTPError(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("file too large: %d bytes (max %d bytes)", fileHeader.Size, cfg.MaxSize))
+	}
+
+	if len(cfg.AllowedTypes) > 0 {
+		contentType, err := sniffContentType(fileHeader)
+		if err != nil {
+			return "", err
+		}
+		if !contentTypeAllowed(contentType, cfg.AllowedTypes) {
+			return "", NewHTTPError(http.StatusUnsupportedMediaType,
+				fmt.Sprintf("file type %s is not allowed", contentType))
+		}
+	}
+
+	dst := filepath.Join(dstDir, generateRandomID()+safeExtension(fileHeader.Filename))
+	if err := c.SaveUploadedFile(fileHeader, dst); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// sniffContentType detects the content type of an uploaded file from its first bytes.
+func sniffContentType(fileHeader *multipart.FileHeader) (string, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := src.Close(); err != nil {
+			log.Printf("Failed to close source file: %v", err)
+		}
+	}()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(src, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	if err != nil {
+		return "application/octet-stream", nil
+	}
+	return mediaType, nil
+}
+
+// safeExtension returns the lowercased extension of filename if it only
+// contains letters and digits, or an empty string otherwise.
+func safeExtension(filename string) string {
+	ext := strings.ToLower(filepath.Ext(sanitizeFilename(filename)))
+	if len(ext) < 2 || !alphanumRegex.MatchString(ext[1:]) {
+		return ""
+	}
+	return ext
+}
+
 // ChunkedJSON sends JSON in chunks (for large responses).
 func (c *Context) ChunkedJSON(v any) error {
 	c.SetHeader("Content-Type", "application/json")
@@ -177,51 +383,52 @@ func (c *Context) ChunkedJSON(v any) error {
 	if err != nil {
 		return err
 	}
-
-	_, err = c.Res.Write(data)
-	if flusher, ok := c.Res.(http.Flusher); ok {
-		flusher.Flush()
-	}
-
-	return err
+	return c.writeChunk(data)
 }
 
 // StreamJSON streams JSON objects one by one.
+// It returns context.Canceled if the client disconnects before items is
+// closed, and the write error if a write to the client fails.
 func (c *Context) StreamJSON(items <-chan any) error {
 	c.SetHeader("Content-Type", "application/json")
 	c.SetHeader("Transfer-Encoding", "chunked")
 
 	// Start array
-	_, _ = c.Res.Write([]byte("["))
-	if flusher, ok := c.Res.(http.Flusher); ok {
-		flusher.Flush()
+	if err := c.writeChunk([]byte("[")); err != nil {
+		return err
 	}
 
 	first := true
-	for item := range items {
-		if !first {
-			_, _ = c.Res.Write([]byte(","))
+	for {
+		var item any
+		select {
+		case <-c.Done():
+			return c.Err()
+		case next, ok := <-items:
+			if !ok {
+				return c.endJSONStream()
+			}
+			item = next
 		}
-		first = false
 
 		data, err := jsonMarshal(item)
 		if err != nil {
 			return err
 		}
-
-		_, _ = c.Res.Write(data)
-		if flusher, ok := c.Res.(http.Flusher); ok {
-			flusher.Flush()
+		if !first {
+			data = append([]byte(","), data...)
 		}
-	}
+		first = false
 
-	// End array
-	_, _ = c.Res.Write([]byte("]"))
-	if flusher, ok := c.Res.(http.Flusher); ok {
-		flusher.Flush()
+		if err := c.writeChunk(data); err != nil {
+			return err
+		}
 	}
+}
 
-	return nil
+// endJSONStream closes the JSON array written by StreamJSON.
+func (c *Context) endJSONStream() error {
+	return c.writeChunk([]byte("]"))
 }
 
 // validateFilePath checks if a file path is safe and doesn't contain directory traversal attempts.
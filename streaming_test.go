@@ -0,0 +1,395 @@
+package ginji
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStreamJSONStopsOnClientDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/stream", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	c := NewTestContext(w, req)
+
+	items := make(chan any)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.StreamJSON(items)
+	}()
+
+	items <- H{"n": 1}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("StreamJSON did not return after client disconnect")
+	}
+}
+
+func TestStreamJSONCompletes(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewTestContext(w, httptest.NewRequest("GET", "/stream", nil))
+
+	items := make(chan any, 2)
+	items <- 1
+	items <- 2
+	close(items)
+
+	if err := c.StreamJSON(items); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if w.Body.String() != "[1,2]" {
+		t.Errorf("Expected [1,2], got %s", w.Body.String())
+	}
+}
+
+func TestStreamStopsOnCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("GET", "/stream", nil).WithContext(ctx)
+	c := NewTestContext(httptest.NewRecorder(), req)
+
+	err := c.Stream("text/plain", strings.NewReader("data"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSSEBroadcasterStopsOnClientDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	c := NewTestContext(httptest.NewRecorder(), req)
+
+	b := NewSSEBroadcaster()
+	done := make(chan struct{})
+	go func() {
+		b.ServeSSE(c)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for b.Count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ServeSSE did not return after client disconnect")
+	}
+	if b.Count() != 0 {
+		t.Errorf("Expected client to be unregistered, got %d clients", b.Count())
+	}
+}
+
+// closingRecorder is a ResponseRecorder that counts writes made after
+// close, i.e. after the request ended.
+type closingRecorder struct {
+	*httptest.ResponseRecorder
+	mu        sync.Mutex
+	closed    bool
+	lateWrite int
+}
+
+func (w *closingRecorder) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		w.lateWrite++
+	}
+	return w.ResponseRecorder.Write(b)
+}
+
+func (w *closingRecorder) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+}
+
+func TestSSEKeepAliveStopsWithRequest(t *testing.T) {
+	app := New()
+	app.Get("/events", func(c *Context) error {
+		c.SSE(func(stream *SSEStream) {
+			stream.SetKeepAlive(time.Millisecond)
+			stream.StartKeepAlive()
+			time.Sleep(10 * time.Millisecond)
+			// Returns without StopKeepAlive
+		})
+		return nil
+	})
+
+	w := &closingRecorder{ResponseRecorder: httptest.NewRecorder()}
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/events", nil))
+	w.close()
+	time.Sleep(20 * time.Millisecond)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !strings.Contains(w.Body.String(), ": keep-alive") {
+		t.Errorf("Expected keep-alive comments during the request, got %q", w.Body.String())
+	}
+	if w.lateWrite != 0 {
+		t.Errorf("Expected keep-alive to stop with the request, got %d late writes", w.lateWrite)
+	}
+
+	// StopKeepAlive can be called again once stopped
+	stream := NewSSEStream(NewTestContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)))
+	stream.StartKeepAlive()
+	stream.StopKeepAlive()
+	stream.StopKeepAlive()
+}
+
+func TestSSEResumeFromLastEventID(t *testing.T) {
+	events := []string{"a", "b", "c", "d"}
+
+	app := New()
+	app.Get("/events", func(c *Context) error {
+		c.SSE(func(stream *SSEStream) {
+			_ = stream.SendRetry(3 * time.Second)
+			start := 0
+			if last := c.LastEventID(); last != "" {
+				fmt.Sscan(last, &start)
+			}
+			if stream.LastEventID() != "" {
+				t.Errorf("Expected no sent event ID yet, got %q", stream.LastEventID())
+			}
+			for i := start; i < len(events); i++ {
+				_ = stream.Send(SSEEvent{ID: strconv.Itoa(i + 1), Data: events[i]})
+			}
+			if stream.LastEventID() != "4" {
+				t.Errorf("Expected last sent event ID 4, got %q", stream.LastEventID())
+			}
+		})
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Last-Event-ID", "2")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	expected := "retry: 3000\n\nid: 3\ndata: c\n\nid: 4\ndata: d\n\n"
+	if w.Body.String() != expected {
+		t.Errorf("Expected resumed stream %q, got %q", expected, w.Body.String())
+	}
+
+	c := NewTestContext(httptest.NewRecorder(), req)
+	if c.LastEventID() != "2" {
+		t.Errorf("Expected Last-Event-ID 2, got %q", c.LastEventID())
+	}
+}
+
+func TestSaveFormFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.Mkdir("uploads", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 32)...)
+	cfg := UploadConfig{MaxSize: 1024, AllowedTypes: []string{"image/*"}}
+
+	app := New()
+	app.Post("/upload", func(c *Context) error {
+		path, err := c.SaveFormFile("file", "uploads", cfg)
+		if err != nil {
+			c.AbortWithError(StatusInternalServerError, err)
+			return nil
+		}
+		return c.Text(StatusOK, path)
+	})
+
+	w := PerformMultipartRequest(app, "POST", "/upload", nil, map[string][]byte{"file": png})
+	if w.Code != StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	saved := w.Body.String()
+	if filepath.Dir(saved) != "uploads" {
+		t.Errorf("Expected file in uploads, got %s", saved)
+	}
+	if data, err := os.ReadFile(saved); err != nil || !bytes.Equal(data, png) {
+		t.Errorf("Saved file content mismatch: %v", err)
+	}
+
+	// Second upload gets a distinct name
+	w = PerformMultipartRequest(app, "POST", "/upload", nil, map[string][]byte{"file": png})
+	if w.Body.String() == saved {
+		t.Error("Expected a unique filename for each upload")
+	}
+
+	// Disallowed content type is sniffed, not trusted
+	w = PerformMultipartRequest(app, "POST", "/upload", nil, map[string][]byte{"file": []byte("plain text")})
+	if w.Code != StatusUnsupportedMediaType {
+		t.Errorf("Expected status 415, got %d", w.Code)
+	}
+
+	// Too large
+	w = PerformMultipartRequest(app, "POST", "/upload", nil, map[string][]byte{"file": bytes.Repeat(png, 100)})
+	if w.Code != StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+
+	// Far too large: reading stops at the limit instead of receiving it all
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "huge.png")
+	_, _ = part.Write(png)
+	_, _ = part.Write(make([]byte, 4<<20))
+	_ = mw.Close()
+	counter := &countingReader{r: &body}
+	req := httptest.NewRequest("POST", "/upload", counter)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+	if w.Code != StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+	if counter.n > cfg.MaxSize+uploadFormOverhead+64<<10 {
+		t.Errorf("Expected body reading to stop near the limit, read %d bytes", counter.n)
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func TestSafeExtension(t *testing.T) {
+	tests := map[string]string{
+		"photo.PNG":        ".png",
+		"../../etc/passwd": "",
+		"archive.tar.gz":   ".gz",
+		"evil.p\"hp":       ".php",
+		"noext":            "",
+		"weird.ph p":       "",
+	}
+	for name, expected := range tests {
+		if got := safeExtension(name); got != expected {
+			t.Errorf("safeExtension(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+// brokenPipeWriter fails every write as if the client had disconnected.
+type brokenPipeWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w brokenPipeWriter) Write([]byte) (int, error) {
+	return 0, syscall.EPIPE
+}
+
+func TestStreamJSONStopsOnWriteError(t *testing.T) {
+	var logs bytes.Buffer
+	app := New()
+	app.Logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	items := make(chan any, 3)
+	items <- 1
+	items <- 2
+	items <- 3
+	close(items)
+
+	var streamErr error
+	app.Get("/stream", func(c *Context) error {
+		streamErr = c.StreamJSON(items)
+		return nil
+	})
+	app.ServeHTTP(brokenPipeWriter{httptest.NewRecorder()}, httptest.NewRequest("GET", "/stream", nil))
+
+	if !errors.Is(streamErr, syscall.EPIPE) {
+		t.Errorf("Expected broken pipe error, got %v", streamErr)
+	}
+	if len(items) != 3 {
+		t.Errorf("Expected the stream to stop before consuming items, %d left", len(items))
+	}
+	if !strings.Contains(logs.String(), "level=DEBUG msg=\"Client disconnected during stream\"") {
+		t.Errorf("Expected disconnect to be logged at debug level, got %s", logs.String())
+	}
+}
+
+func TestStreamingFlushesThroughContextWriter(t *testing.T) {
+	app := New()
+	app.Get("/chunked", func(c *Context) error {
+		return c.ChunkedJSON(map[string]int{"n": 1})
+	})
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/chunked", nil))
+	if !w.Flushed {
+		t.Error("Expected the response to be flushed")
+	}
+	if strings.TrimSpace(w.Body.String()) != `{"n":1}` {
+		t.Errorf("Unexpected body %q", w.Body.String())
+	}
+}
+
+func TestSetTrailer(t *testing.T) {
+	app := New()
+	app.Get("/stream", func(c *Context) error {
+		c.SetTrailer("grpc-status", "unknown")
+		if err := c.Stream("text/plain", strings.NewReader("hello")); err != nil {
+			return err
+		}
+		// Set once the body is written, declared or not
+		c.SetTrailer("Grpc-Status", "0")
+		c.SetTrailer("X-Checksum", "5d41402a")
+		return nil
+	})
+	server := httptest.NewServer(app)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// The client moves declared trailers from the Trailer header to
+	// resp.Trailer, with values filled in once the body is read
+	if _, declared := resp.Trailer["Grpc-Status"]; !declared || len(resp.Trailer) != 1 {
+		t.Errorf("Expected only Grpc-Status to be declared, got %v", resp.Trailer)
+	}
+	if resp.Header.Get("Grpc-Status") != "" {
+		t.Error("Expected trailer not to be sent as a header")
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("Expected body hello, got %q", body)
+	}
+	if got := resp.Trailer.Get("Grpc-Status"); got != "0" {
+		t.Errorf("Expected Grpc-Status trailer 0, got %q", got)
+	}
+	if got := resp.Trailer.Get("X-Checksum"); got != "5d41402a" {
+		t.Errorf("Expected X-Checksum trailer, got %q", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/url"
 	"strings"
@@ -89,6 +90,7 @@ func PerformMultipartRequest(engine *Engine, method, path string, fields map[str
 // Request is a fluent request builder for testing.
 type Request struct {
 	engine  *Engine
+	client  *TestClient // optional client whose cookie jar is used
 	method  string
 	path    string
 	headers map[string]string
@@ -153,11 +155,124 @@ func (r *Request) Do() *httptest.ResponseRecorder {
 		req.AddCookie(cookie)
 	}
 
+	// Attach cookies stored in the client's jar
+	if r.client != nil {
+		for _, cookie := range r.client.jar.Cookies(r.client.cookieURL(req)) {
+			req.AddCookie(cookie)
+		}
+	}
+
 	w := httptest.NewRecorder()
 	r.engine.ServeHTTP(w, req)
+
+	// Persist any cookies set by the response
+	if r.client != nil {
+		if cookies := w.Result().Cookies(); len(cookies) > 0 {
+			r.client.jar.SetCookies(r.client.cookieURL(req), cookies)
+		}
+	}
 	return w
 }
 
+// Send executes the request and returns the wrapped response.
+func (r *Request) Send() *Response {
+	return NewResponse(r.Do())
+}
+
+// TestClient executes requests against an engine and keeps a cookie jar
+// across calls, which makes it possible to test session and auth flows.
+type TestClient struct {
+	engine *Engine
+	jar    http.CookieJar
+}
+
+// NewTestClient creates a new test client with an empty cookie jar.
+func NewTestClient(engine *Engine) *TestClient {
+	jar, _ := cookiejar.New(nil)
+	return &TestClient{
+		engine: engine,
+		jar:    jar,
+	}
+}
+
+// Test switches to TestMode and returns a client for the engine:
+//
+//	client := app.Test()
+//	res := client.Post("/users").JSON(user).Send()
+//	if res.Status() != http.StatusCreated { ... }
+//
+// The mode is global, so tests that depend on another mode should restore it.
+func (engine *Engine) Test() *TestClient {
+	SetMode(TestMode)
+	return NewTestClient(engine)
+}
+
+// Request creates a new request builder that shares the client's cookie jar.
+func (tc *TestClient) Request(method, path string) *Request {
+	r := NewRequest(tc.engine, method, path)
+	r.client = tc
+	return r
+}
+
+// Get creates a GET request builder bound to the client.
+func (tc *TestClient) Get(path string) *Request {
+	return tc.Request(http.MethodGet, path)
+}
+
+// Post creates a POST request builder bound to the client.
+func (tc *TestClient) Post(path string) *Request {
+	return tc.Request(http.MethodPost, path)
+}
+
+// Put creates a PUT request builder bound to the client.
+func (tc *TestClient) Put(path string) *Request {
+	return tc.Request(http.MethodPut, path)
+}
+
+// Patch creates a PATCH request builder bound to the client.
+func (tc *TestClient) Patch(path string) *Request {
+	return tc.Request(http.MethodPatch, path)
+}
+
+// Delete creates a DELETE request builder bound to the client.
+func (tc *TestClient) Delete(path string) *Request {
+	return tc.Request(http.MethodDelete, path)
+}
+
+// Head creates a HEAD request builder bound to the client.
+func (tc *TestClient) Head(path string) *Request {
+	return tc.Request(http.MethodHead, path)
+}
+
+// Options creates an OPTIONS request builder bound to the client.
+func (tc *TestClient) Options(path string) *Request {
+	return tc.Request(http.MethodOptions, path)
+}
+
+// Cookies returns the cookies the jar would send for the given path.
+func (tc *TestClient) Cookies(path string) []*http.Cookie {
+	return tc.jar.Cookies(tc.cookieURL(httptest.NewRequest(http.MethodGet, path, nil)))
+}
+
+// SetCookie stores a cookie in the jar for the given path.
+func (tc *TestClient) SetCookie(path string, cookie *http.Cookie) {
+	tc.jar.SetCookies(tc.cookieURL(httptest.NewRequest(http.MethodGet, path, nil)), []*http.Cookie{cookie})
+}
+
+// ClearCookies discards all cookies stored in the jar.
+func (tc *TestClient) ClearCookies() {
+	tc.jar, _ = cookiejar.New(nil)
+}
+
+// cookieURL builds the absolute URL used to key cookies in the jar.
+func (tc *TestClient) cookieURL(req *http.Request) *url.URL {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	return &url.URL{Scheme: scheme, Host: req.Host, Path: req.URL.Path}
+}
+
 // Response wraps httptest.ResponseRecorder with helper methods.
 type Response struct {
 	*httptest.ResponseRecorder
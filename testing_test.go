@@ -231,3 +231,103 @@ func TestPerformMultipartRequest(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", w.Code)
 	}
 }
+
+func TestTestClientCookieJar(t *testing.T) {
+	app := New()
+	app.Post("/login", func(c *Context) error {
+		c.SetCookie(&http.Cookie{Name: "session", Value: "s3cr3t", Path: "/"})
+		return c.Text(StatusOK, "logged in")
+	})
+	app.Get("/me", func(c *Context) error {
+		cookie, err := c.Cookie("session")
+		if err != nil {
+			return c.Text(StatusUnauthorized, "no session")
+		}
+		return c.Text(StatusOK, cookie.Value)
+	})
+	app.Post("/logout", func(c *Context) error {
+		c.SetCookie(&http.Cookie{Name: "session", Value: "", Path: "/", MaxAge: -1})
+		return c.Text(StatusOK, "logged out")
+	})
+
+	client := NewTestClient(app)
+
+	w := client.Get("/me").Do()
+	AssertStatus(t, w, StatusUnauthorized)
+
+	w = client.Post("/login").Do()
+	AssertStatus(t, w, StatusOK)
+
+	w = client.Get("/me").Do()
+	AssertStatus(t, w, StatusOK)
+	AssertBody(t, w, "s3cr3t")
+
+	if len(client.Cookies("/me")) != 1 {
+		t.Errorf("Expected 1 cookie in jar, got %d", len(client.Cookies("/me")))
+	}
+
+	client.Post("/logout").Do()
+	w = client.Get("/me").Do()
+	AssertStatus(t, w, StatusUnauthorized)
+}
+
+func TestTestClientIsolation(t *testing.T) {
+	app := New()
+	app.Get("/me", func(c *Context) error {
+		if _, err := c.Cookie("session"); err != nil {
+			return c.Text(StatusUnauthorized, "no session")
+		}
+		return c.Text(StatusOK, "ok")
+	})
+
+	client := NewTestClient(app)
+	client.SetCookie("/", &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+
+	AssertStatus(t, client.Get("/me").Do(), StatusOK)
+	AssertStatus(t, NewRequest(app, "GET", "/me").Do(), StatusUnauthorized)
+
+	client.ClearCookies()
+	AssertStatus(t, client.Get("/me").Do(), StatusUnauthorized)
+}
+
+func TestEngineTest(t *testing.T) {
+	defer SetMode(GetMode())
+
+	type user struct {
+		Name string `json:"name"`
+	}
+	app := New()
+	app.Put("/users/:id", func(c *Context) error {
+		var u user
+		if err := c.BindJSON(&u); err != nil {
+			return err
+		}
+		c.SetCookie(&http.Cookie{Name: "last", Value: c.Param("id"), Path: "/"})
+		return c.JSON(StatusOK, u)
+	})
+	app.Delete("/users/:id", func(c *Context) error {
+		last, err := c.Cookie("last")
+		if err != nil {
+			return c.Text(StatusBadRequest, "no cookie")
+		}
+		return c.Text(StatusOK, last.Value)
+	})
+
+	client := app.Test()
+	if GetMode() != TestMode {
+		t.Errorf("Expected TestMode, got %v", GetMode())
+	}
+
+	res := client.Put("/users/7").JSON(user{Name: "ada"}).Send()
+	if res.Status() != StatusOK {
+		t.Fatalf("Expected 200, got %d", res.Status())
+	}
+	var got user
+	if err := res.JSON(&got); err != nil || got.Name != "ada" {
+		t.Errorf("Expected decoded user, got %+v (%v)", got, err)
+	}
+
+	if body := client.Delete("/users/7").Send().String(); body != "7" {
+		t.Errorf("Expected cookie from previous response, got %q", body)
+	}
+}
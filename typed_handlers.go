@@ -1,8 +1,10 @@
 package ginji
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"reflect"
 )
 
@@ -13,6 +15,67 @@ type TypedHandler[Req any, Res any] func(*Context, Req) (Res, error)
 // EmptyRequest is used when a handler doesn't need a request body.
 type EmptyRequest struct{}
 
+// Responder can be implemented by typed handler response types to control
+// how they are written, including the status code and headers.
+// When a response implements Responder, Respond is called instead of
+// encoding the value as JSON with 200 OK.
+type Responder interface {
+	Respond(c *Context) error
+}
+
+// Created is a Responder that writes 201 Created with an optional
+// Location header and Body encoded as JSON.
+type Created[T any] struct {
+	Location string
+	Body     T
+}
+
+// Respond implements Responder.
+func (r Created[T]) Respond(c *Context) error {
+	if r.Location != "" {
+		c.SetHeader("Location", r.Location)
+	}
+	return c.JSON(StatusCreated, r.Body)
+}
+
+// StreamResponse is a Responder that streams Reader as the response body
+// instead of encoding it as JSON, e.g. for CSV exports. ContentType
+// defaults to application/octet-stream, and Reader is closed afterwards
+// if it implements io.Closer. Typed handlers may also return a plain
+// io.Reader, which is streamed the same way.
+type StreamResponse struct {
+	ContentType string
+	Reader      io.Reader
+}
+
+// Respond implements Responder.
+func (r StreamResponse) Respond(c *Context) error {
+	if closer, ok := r.Reader.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				log.Printf("Failed to close stream response: %v", err)
+			}
+		}()
+	}
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return c.Stream(contentType, r.Reader)
+}
+
+// asResponder returns the Responder that writes res, if it writes itself
+// or is a stream.
+func asResponder(res any) (Responder, bool) {
+	switch r := res.(type) {
+	case Responder:
+		return r, true
+	case io.Reader:
+		return StreamResponse{Reader: r}, true
+	}
+	return nil, false
+}
+
 // TypedHandlerFunc wraps a typed handler for use with standard routing.
 // It automatically handles request binding, validation, and response marshaling.
 func TypedHandlerFunc[Req any, Res any](handler TypedHandler[Req, Res]) Handler {
@@ -37,12 +100,12 @@ func TypedHandlerFunc[Req any, Res any](handler TypedHandler[Req, Res]) Handler
 			// Attempt to bind the request with detailed error messages
 			if err := bindTypedRequest(c, &req); err != nil {
 				errorMsg := fmt.Sprintf("Failed to bind request to type %s: %v", reqTypeName, err)
-				c.AbortWithError(StatusBadRequest, NewHTTPError(StatusBadRequest, errorMsg))
+				c.AbortWithError(StatusBadRequest, newBindHTTPError(errorMsg, err))
 				return nil
 			}
 
 			// Validate the bound request
-			if err := validateStruct(req); err != nil {
+			if err := c.validate(req); err != nil {
 				c.AbortWithError(StatusUnprocessableEntity, err)
 				return nil
 			}
@@ -69,6 +132,14 @@ func TypedHandlerFunc[Req any, Res any](handler TypedHandler[Req, Res]) Handler
 			return nil
 		}
 
+		// Let the response write itself if it knows how
+		if responder, ok := asResponder(res); ok {
+			if err := responder.Respond(c); err != nil {
+				c.AbortWithError(StatusInternalServerError, err)
+			}
+			return nil
+		}
+
 		// Marshal and send the response
 		if err := c.JSON(StatusOK, res); err != nil {
 			c.AbortWithError(StatusInternalServerError, NewHTTPError(
@@ -102,8 +173,18 @@ func getTypeName(t reflect.Type) string {
 }
 
 // bindTypedRequest attempts to bind the request from various sources.
-// It tries to bind from the request body, query params, and path params.
+// It tries to bind from the request body, query params, and path params,
+// then from claims, last so that request data cannot override them.
 func bindTypedRequest(c *Context, v any) error {
+	if err := bindRequestSources(c, v); err != nil {
+		return err
+	}
+	return bindClaims(c, v)
+}
+
+// bindRequestSources binds path parameters and, depending on the method,
+// the query or the body.
+func bindRequestSources(c *Context, v any) error {
 	method := c.Req.Method
 	contentType := c.Header("Content-Type")
 
@@ -145,10 +226,11 @@ func bindTypedRequest(c *Context, v any) error {
 		}
 
 		// Then bind body based on content type
-		switch contentType {
-		case "", "application/json":
+		mt := mediaType(contentType)
+		switch {
+		case mt == "" || isJSONMediaType(mt):
 			if c.Req.Body != nil {
-				if err := json.NewDecoder(c.Req.Body).Decode(v); err != nil {
+				if err := decodeJSONBody(c.Req.Body, v, c.disallowUnknownFields()); err != nil {
 					return &BindingError{
 						Source:      "JSON body",
 						Cause:       err,
@@ -156,8 +238,8 @@ func bindTypedRequest(c *Context, v any) error {
 					}
 				}
 			}
-		case "application/x-www-form-urlencoded", "multipart/form-data":
-			if err := bindForm(c.Req, v); err != nil {
+		case isFormMediaType(mt):
+			if err := bindForm(c.Req, v, c.maxMultipartMemory()); err != nil {
 				return &BindingError{
 					Source:      "form data",
 					Cause:       err,
@@ -196,6 +278,23 @@ func (e *BindingError) Unwrap() error {
 	return e.Cause
 }
 
+// newBindHTTPError converts a binding failure into a 400 HTTPError,
+// attaching per-field details when conversion errors are available.
+// Missing claims mean the route is misconfigured and give a 500 instead.
+func newBindHTTPError(message string, err error) *HTTPError {
+	if errors.Is(err, ErrNoClaims) {
+		httpErr := NewHTTPError(StatusInternalServerError)
+		httpErr.internal = err
+		return httpErr
+	}
+	httpErr := NewHTTPError(StatusBadRequest, message)
+	var fieldErrs BindFieldErrors
+	if errors.As(err, &fieldErrs) {
+		httpErr.Details = fieldErrs.Fields()
+	}
+	return httpErr
+}
+
 // TypedHandlerWithStatus is like TypedHandler but also returns an HTTP status code.
 type TypedHandlerWithStatus[Req any, Res any] func(*Context, Req) (int, Res, error)
 
@@ -221,11 +320,11 @@ func TypedHandlerWithStatusFunc[Req any, Res any](handler TypedHandlerWithStatus
 		if !isEmptyReq {
 			if err := bindTypedRequest(c, &req); err != nil {
 				errorMsg := fmt.Sprintf("Failed to bind request to type %s: %v", reqTypeName, err)
-				c.AbortWithError(StatusBadRequest, NewHTTPError(StatusBadRequest, errorMsg))
+				c.AbortWithError(StatusBadRequest, newBindHTTPError(errorMsg, err))
 				return nil
 			}
 
-			if err := validateStruct(req); err != nil {
+			if err := c.validate(req); err != nil {
 				c.AbortWithError(StatusUnprocessableEntity, err)
 				return nil
 			}
@@ -3,6 +3,7 @@ package ginji
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -255,3 +256,77 @@ func BenchmarkTypeCaching(b *testing.B) {
 		}
 	})
 }
+
+// TestBindFieldErrorsCollected tests that all conversion failures are reported.
+func TestBindFieldErrorsCollected(t *testing.T) {
+	app := New()
+
+	type SearchRequest struct {
+		Page   int     `query:"page"`
+		Limit  uint    `query:"limit"`
+		Score  float64 `query:"score"`
+		Active bool    `query:"active"`
+		Term   string  `query:"q"`
+	}
+
+	app.Get("/search", TypedHandlerFunc(func(c *Context, req SearchRequest) (SearchRequest, error) {
+		return req, nil
+	}))
+
+	req := httptest.NewRequest("GET", "/search?page=abc&limit=-1&score=x&active=maybe&q=go", nil)
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+
+	if rec.Code != 400 {
+		t.Fatalf("Expected status 400, got %d", rec.Code)
+	}
+
+	var resp struct {
+		Details map[string]string `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	expected := map[string]string{
+		"page":   "not an integer",
+		"limit":  "not an unsigned integer",
+		"score":  "not a number",
+		"active": "not a boolean",
+	}
+	for field, msg := range expected {
+		if resp.Details[field] != msg {
+			t.Errorf("Expected details[%q] = %q, got %q", field, msg, resp.Details[field])
+		}
+	}
+	if _, ok := resp.Details["q"]; ok {
+		t.Error("Did not expect an error for a valid field")
+	}
+}
+
+// TestBindFieldErrorsDefaultHandler tests that BindFieldErrors map to a 400.
+func TestBindFieldErrorsDefaultHandler(t *testing.T) {
+	type Query struct {
+		Page int `query:"page"`
+	}
+
+	c, rec := NewTestContextWithRecorder("GET", "/?page=abc")
+	var q Query
+	err := c.BindQuery(&q)
+
+	var fieldErrs BindFieldErrors
+	if !errors.As(err, &fieldErrs) || len(fieldErrs) != 1 {
+		t.Fatalf("Expected one BindFieldError, got %v", err)
+	}
+	if fieldErrs[0].Source != "query" || fieldErrs[0].Value != "abc" {
+		t.Errorf("Unexpected field error: %+v", fieldErrs[0])
+	}
+
+	handleError(c, err)
+	if rec.Code != 400 {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "not an integer") {
+		t.Errorf("Expected field message in body, got: %s", rec.Body.String())
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -56,8 +58,8 @@ func TestTypedHandlerPOST(t *testing.T) {
 
 	app.ServeHTTP(rec, req)
 
-	if rec.Code != StatusOK {
-		t.Errorf("Expected status %d, got %d", StatusOK, rec.Code)
+	if rec.Code != StatusCreated {
+		t.Errorf("Expected status %d, got %d", StatusCreated, rec.Code)
 	}
 
 	var res CreateUserResponse
@@ -137,6 +139,44 @@ func TestTypedHandlerValidation(t *testing.T) {
 	}
 }
 
+func TestTypedHandlerValidationErrorsInBody(t *testing.T) {
+	type SignupRequest struct {
+		Name  string `json:"name" validate:"required"`
+		Email string `json:"email" validate:"email"`
+		Age   int    `json:"age" validate:"max=150"`
+	}
+
+	handler := func(c *Context, req SignupRequest) (CreateUserResponse, error) {
+		return CreateUserResponse{ID: 1}, nil
+	}
+
+	app := New()
+	app.Typed().Post("/typed", handler)
+	app.Post("/func", TypedHandlerFunc(handler))
+
+	for _, path := range []string{"/typed", "/func"} {
+		body := strings.NewReader(`{"email":"invalid","age":200}`)
+		rec := PerformRequest(app, "POST", path, body)
+
+		if rec.Code != StatusUnprocessableEntity {
+			t.Errorf("%s: expected status 422, got %d", path, rec.Code)
+		}
+
+		var res ErrorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", path, err)
+		}
+
+		var fields []string
+		for _, e := range res.Errors {
+			fields = append(fields, e.Field)
+		}
+		if strings.Join(fields, ",") != "Name,Email,Age" {
+			t.Errorf("%s: expected errors for Name,Email,Age, got %v", path, fields)
+		}
+	}
+}
+
 func TestTypedHandlerPUT(t *testing.T) {
 	app := New()
 
@@ -302,3 +342,190 @@ func TestEmptyRequestAndResponse(t *testing.T) {
 		t.Errorf("Expected status %d for empty response, got %d", StatusNoContent, rec.Code)
 	}
 }
+
+type acceptedResponse struct {
+	JobID string `json:"job_id"`
+}
+
+func (r acceptedResponse) Respond(c *Context) error {
+	c.SetHeader("X-Job-ID", r.JobID)
+	return c.JSON(StatusAccepted, r)
+}
+
+func TestTypedHandlerResponder(t *testing.T) {
+	app := New()
+
+	app.Typed().Post("/users", func(c *Context, req CreateUserRequest) (Created[CreateUserResponse], error) {
+		return Created[CreateUserResponse]{
+			Location: "/users/1",
+			Body:     CreateUserResponse{ID: 1, Name: req.Name, Email: req.Email},
+		}, nil
+	})
+	app.Post("/jobs", TypedHandlerFunc(func(c *Context, _ EmptyRequest) (acceptedResponse, error) {
+		return acceptedResponse{JobID: "42"}, nil
+	}))
+
+	body, _ := json.Marshal(CreateUserRequest{Name: "Jane", Email: "jane@example.com", Age: 28})
+	req := httptest.NewRequest("POST", "/users", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+
+	if rec.Code != StatusCreated {
+		t.Errorf("Expected status %d, got %d", StatusCreated, rec.Code)
+	}
+	if rec.Header().Get("Location") != "/users/1" {
+		t.Errorf("Expected Location /users/1, got %s", rec.Header().Get("Location"))
+	}
+
+	var res CreateUserResponse
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if res.Name != "Jane" {
+		t.Errorf("Expected name Jane, got %s", res.Name)
+	}
+
+	req = httptest.NewRequest("POST", "/jobs", nil)
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+
+	if rec.Code != StatusAccepted {
+		t.Errorf("Expected status %d, got %d", StatusAccepted, rec.Code)
+	}
+	if rec.Header().Get("X-Job-ID") != "42" {
+		t.Errorf("Expected X-Job-ID 42, got %s", rec.Header().Get("X-Job-ID"))
+	}
+}
+
+func TestTypedPostStatusOptOut(t *testing.T) {
+	app := New()
+
+	app.Typed().PostStatus(StatusOK).Post("/users", func(c *Context, req CreateUserRequest) (CreateUserResponse, error) {
+		return CreateUserResponse{ID: 1, Name: req.Name}, nil
+	})
+
+	body, _ := json.Marshal(CreateUserRequest{Name: "Jane", Email: "jane@example.com", Age: 28})
+	req := httptest.NewRequest("POST", "/users", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+
+	if rec.Code != StatusOK {
+		t.Errorf("Expected status %d, got %d", StatusOK, rec.Code)
+	}
+}
+
+type OrgScope struct {
+	Org string `path:"org" validate:"required"`
+}
+
+type GetProjectRequest struct {
+	OrgScope
+	ID      string `path:"id" validate:"required"`
+	Verbose bool   `query:"verbose"`
+}
+
+type UpdateProjectRequest struct {
+	*OrgScope
+	ID   string `path:"id"`
+	Name string `json:"name"`
+}
+
+func TestTypedHandlerEmbeddedRequest(t *testing.T) {
+	app := New()
+	orgs := app.Group("/orgs/:org")
+
+	orgs.Typed().Get("/projects/:id", func(c *Context, req GetProjectRequest) (map[string]any, error) {
+		return map[string]any{"org": req.Org, "id": req.ID, "verbose": req.Verbose}, nil
+	})
+
+	rec := PerformRequest(app, "GET", "/orgs/acme/projects/42?verbose=true", nil)
+	if rec.Code != StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", StatusOK, rec.Code, rec.Body.String())
+	}
+
+	var res map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if res["org"] != "acme" || res["id"] != "42" || res["verbose"] != true {
+		t.Errorf("Expected embedded path fields to be bound, got %v", res)
+	}
+
+	// Embedded pointers are bound when already allocated
+	req := UpdateProjectRequest{OrgScope: &OrgScope{}}
+	if err := bindParams(map[string]string{"org": "acme", "id": "7"}, &req); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if req.Org != "acme" || req.ID != "7" {
+		t.Errorf("Expected org acme and id 7, got %s %s", req.Org, req.ID)
+	}
+
+	// Bind discovers sources through embedded structs
+	sources := bindSources(reflect.TypeOf(GetProjectRequest{}))
+	if !sources["path"] || !sources["query"] {
+		t.Errorf("Expected path and query sources, got %v", sources)
+	}
+}
+
+func TestTypedHandlerStreamResponse(t *testing.T) {
+	app := New()
+
+	app.Typed().Get("/export.csv", func(c *Context, _ EmptyRequest) (StreamResponse, error) {
+		return StreamResponse{ContentType: "text/csv", Reader: strings.NewReader("id,name\n1,Jane\n")}, nil
+	})
+	app.Get("/raw", TypedHandlerFunc(func(c *Context, _ EmptyRequest) (*bytes.Buffer, error) {
+		return bytes.NewBufferString("raw bytes"), nil
+	}))
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/export.csv", nil))
+	if rec.Header().Get("Content-Type") != "text/csv" {
+		t.Errorf("Expected Content-Type text/csv, got %s", rec.Header().Get("Content-Type"))
+	}
+	if rec.Body.String() != "id,name\n1,Jane\n" {
+		t.Errorf("Expected streamed CSV, got %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/raw", nil))
+	if rec.Header().Get("Content-Type") != "application/octet-stream" {
+		t.Errorf("Expected Content-Type application/octet-stream, got %s", rec.Header().Get("Content-Type"))
+	}
+	if rec.Body.String() != "raw bytes" {
+		t.Errorf("Expected raw body, got %q", rec.Body.String())
+	}
+}
+
+func TestTypedHandlerJSONMediaTypes(t *testing.T) {
+	app := New()
+	app.Typed().Post("/users", func(c *Context, req CreateUserRequest) (CreateUserResponse, error) {
+		return CreateUserResponse{ID: 1, Name: req.Name, Email: req.Email}, nil
+	})
+
+	body, _ := json.Marshal(CreateUserRequest{Name: "Jane", Email: "jane@example.com", Age: 28})
+	tests := []struct {
+		contentType string
+		expected    int
+	}{
+		{"application/json", StatusCreated},
+		{"application/json; charset=utf-8", StatusCreated},
+		{"Application/JSON", StatusCreated},
+		{"application/vnd.api+json", StatusCreated},
+		{"application/problem+json; charset=utf-8", StatusCreated},
+		{"application/xml", StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/users", bytes.NewReader(body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			app.ServeHTTP(rec, req)
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
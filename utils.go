@@ -1,20 +1,97 @@
 package ginji
 
 import (
+	"bytes"
+	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // H is a shortcut for map[string]any
 type H map[string]any
 
-// jsonMarshal is a helper for marshaling JSON
+// A is a shortcut for a JSON array. Unlike a nil slice it encodes as []
+// when empty.
+func A(values ...any) []any {
+	if values == nil {
+		return []any{}
+	}
+	return values
+}
+
+// Err returns an error body with the same "error" field as the responses
+// of the default error handler:
+//
+//	return c.JSON(http.StatusConflict, ginji.Err("email already registered"))
+func Err(message string) H {
+	return H{"error": message}
+}
+
+// ErrsH returns an error body listing validation errors under "errors",
+// shaped like the default error handler's 422 responses.
+func ErrsH(errs ValidationErrors) H {
+	if errs == nil {
+		errs = ValidationErrors{}
+	}
+	return H{"error": "Validation failed", "errors": errs}
+}
+
+// JSON encoding options, see SetJSONCanonical and SetJSONEscapeHTML.
+var (
+	jsonCanonical  = false
+	jsonEscapeHTML = true
+)
+
+// SetJSONCanonical enables canonical JSON output for c.JSON and the other
+// JSON writers. Object keys, including struct fields, are sorted so equal
+// values always encode to the same bytes, which keeps ETags and request
+// signatures stable. Number formatting is preserved.
+func SetJSONCanonical(enabled bool) {
+	jsonCanonical = enabled
+}
+
+// SetJSONEscapeHTML controls whether <, > and & are escaped in JSON
+// strings. It is enabled by default, matching encoding/json.
+func SetJSONEscapeHTML(enabled bool) {
+	jsonEscapeHTML = enabled
+}
+
+// jsonMarshal is a helper for marshaling JSON with the package options.
 func jsonMarshal(v any) ([]byte, error) {
-	return json.Marshal(v)
+	data, err := encodeJSONValue(v)
+	if err != nil || !jsonCanonical {
+		return data, err
+	}
+
+	// Round-trip through generic values so that every object is a map,
+	// which encoding/json always writes with sorted keys
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return encodeJSONValue(generic)
+}
+
+// encodeJSONValue encodes v honoring the HTML escaping option.
+func encodeJSONValue(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(jsonEscapeHTML)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
 }
 
 // jsonUnmarshal is a helper for unmarshaling JSON
@@ -22,6 +99,27 @@ func jsonUnmarshal(data []byte, v any) error {
 	return json.Unmarshal(data, v)
 }
 
+// ErrJSONTrailingData is returned when binding a JSON request body that
+// contains data after its first value, e.g. `{"a":1}{"b":2}`.
+var ErrJSONTrailingData = errors.New("request body must contain a single JSON value")
+
+// decodeJSONBody decodes the JSON value in r into v, rejecting anything
+// but whitespace after it. With disallowUnknown, object keys that match no
+// field of v are an error too.
+func decodeJSONBody(r io.Reader, v any, disallowUnknown bool) error {
+	dec := json.NewDecoder(r)
+	if disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return ErrJSONTrailingData
+	}
+	return nil
+}
+
 // bindMap binds a map of strings to a struct based on a tag.
 func bindMap(data map[string][]string, v any, tagName string) error {
 	val := reflect.ValueOf(v)
@@ -33,31 +131,254 @@ func bindMap(data map[string][]string, v any, tagName string) error {
 		return fmt.Errorf("bind target must be a struct")
 	}
 
+	if fieldErrs := bindMapValue(data, val, tagName, ""); len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+	return nil
+}
+
+// bindMapValue binds data into the fields of the struct value val,
+// recursing into embedded structs. Tagged struct fields are bound from
+// keys prefixed with the tag, e.g. "address.city" for `query:"address"`.
+func bindMapValue(data map[string][]string, val reflect.Value, tagName, prefix string) BindFieldErrors {
+	var fieldErrs BindFieldErrors
 	t := val.Type()
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
+		fieldVal := val.Field(i)
 		tag := field.Tag.Get(tagName)
 		if tag == "" {
+			if embedded, ok := embeddedStruct(field, fieldVal); ok {
+				fieldErrs = append(fieldErrs, bindMapValue(data, embedded, tagName, prefix)...)
+			}
+			continue
+		}
+		if tag == "-" {
+			// "-" explicitly excludes the field from binding
+			continue
+		}
+
+		key := prefix + tag
+		if nested, ok := nestedStruct(fieldVal, hasKeyPrefix(data, key+".")); ok {
+			fieldErrs = append(fieldErrs, bindMapValue(data, nested, tagName, key+".")...)
+			continue
+		}
+
+		// Map fields collect bracketed keys such as filter[name]
+		if isStringMap(fieldVal) {
+			if m := bracketMap(data, key); len(m) > 0 && fieldVal.CanSet() {
+				mv := reflect.MakeMapWithSize(fieldVal.Type(), len(m))
+				for k, v := range m {
+					mv.SetMapIndex(reflect.ValueOf(k).Convert(fieldVal.Type().Key()), reflect.ValueOf(v).Convert(fieldVal.Type().Elem()))
+				}
+				fieldVal.Set(mv)
+			}
 			continue
 		}
 
-		// Check if the tag exists in the data
-		if values, ok := data[tag]; ok && len(values) > 0 {
-			fieldVal := val.Field(i)
+		// Check if the key exists in the data
+		if values, ok := data[key]; ok && len(values) > 0 {
 			if fieldVal.CanSet() {
+				// Slice fields take every value of a repeated key
+				if isMultiValue(fieldVal) {
+					fieldErrs = append(fieldErrs, setSliceField(fieldVal, field, key, tagName, values)...)
+					continue
+				}
 				// Use setField for proper type conversion
-				if err := setField(fieldVal, values[0]); err != nil {
-					return fmt.Errorf("failed to set field %s: %w", field.Name, err)
+				if err := setStructField(fieldVal, field, values[0]); err != nil {
+					fieldErrs = append(fieldErrs, newBindFieldError(key, tagName, values[0], fieldVal, err))
 				}
 			}
 		}
 	}
+	return fieldErrs
+}
 
-	return nil
+// isStringMap reports whether field is a map with string keys and values,
+// including named string types, bound from bracketed keys.
+func isStringMap(field reflect.Value) bool {
+	t := field.Type()
+	return t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String
+}
+
+// bracketMap collects the first value of each key[sub] entry in data into
+// a map keyed by sub. Entries with an empty, unterminated or nested
+// subscript are skipped.
+func bracketMap(data map[string][]string, key string) map[string]string {
+	m := make(map[string]string)
+	prefix := key + "["
+	for k, values := range data {
+		if len(values) == 0 || !strings.HasPrefix(k, prefix) || !strings.HasSuffix(k, "]") {
+			continue
+		}
+		sub := k[len(prefix) : len(k)-1]
+		if sub == "" || strings.ContainsAny(sub, "[]") {
+			continue
+		}
+		m[sub] = values[0]
+	}
+	return m
+}
+
+// isMultiValue reports whether field is a slice bound from all values of
+// a repeated key. Byte slices and text unmarshalers bind a single value.
+func isMultiValue(field reflect.Value) bool {
+	t := field.Type()
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 &&
+		!reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// setSliceField sets a slice field from the values of a repeated key,
+// converting each value to the element type. The field is left unchanged
+// if any value fails to convert.
+func setSliceField(field reflect.Value, sf reflect.StructField, key, source string, values []string) BindFieldErrors {
+	var fieldErrs BindFieldErrors
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := setStructField(slice.Index(i), sf, value); err != nil {
+			fieldErrs = append(fieldErrs, newBindFieldError(key, source, value, slice.Index(i), err))
+		}
+	}
+	if len(fieldErrs) == 0 {
+		field.Set(slice)
+	}
+	return fieldErrs
+}
+
+// embeddedStruct returns the struct to bind into when field is an embedded
+// struct or a non-nil pointer to one, so that its promoted fields are bound
+// as if they were declared on the outer struct.
+func embeddedStruct(field reflect.StructField, value reflect.Value) (reflect.Value, bool) {
+	if !field.Anonymous {
+		return reflect.Value{}, false
+	}
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return reflect.Value{}, false
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	return value, true
+}
+
+// BindFieldError describes a request value that could not be converted
+// into the type of its destination field.
+type BindFieldError struct {
+	Field  string // name of the parameter as sent by the client
+	Source string // e.g. "query", "header", "path", "form"
+	Value  string // raw value that failed to convert
+	Err    error  // underlying conversion error
+}
+
+// Error implements the error interface.
+func (e BindFieldError) Error() string {
+	return fmt.Sprintf("invalid %s value %q for '%s': %s", e.Source, e.Value, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying conversion error.
+func (e BindFieldError) Unwrap() error {
+	return e.Err
+}
+
+// BindFieldErrors is a collection of binding conversion errors.
+type BindFieldErrors []BindFieldError
+
+// Error implements the error interface.
+func (be BindFieldErrors) Error() string {
+	if len(be) == 0 {
+		return "binding failed"
+	}
+	return fmt.Sprintf("binding failed on field '%s': %s", be[0].Field, be[0].Err)
+}
+
+// Fields returns a map of field name to error message, suitable for
+// returning to clients as error details.
+func (be BindFieldErrors) Fields() map[string]string {
+	fields := make(map[string]string, len(be))
+	for _, fe := range be {
+		fields[fe.Field] = fe.Err.Error()
+	}
+	return fields
+}
+
+// newBindFieldError creates a BindFieldError with a client-friendly message
+// derived from the destination field type.
+func newBindFieldError(name, source, value string, field reflect.Value, err error) BindFieldError {
+	msg := err.Error()
+	// Custom types and times report their own parse errors
+	if !isTextUnmarshaler(field) {
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			msg = "not an integer"
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			msg = "not an unsigned integer"
+		case reflect.Bool:
+			msg = "not a boolean"
+		case reflect.Float32, reflect.Float64:
+			msg = "not a number"
+		}
+	}
+	return BindFieldError{
+		Field:  name,
+		Source: source,
+		Value:  value,
+		Err:    errors.New(msg),
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// isTextUnmarshaler reports whether field (or a pointer to it) implements
+// encoding.TextUnmarshaler.
+func isTextUnmarshaler(field reflect.Value) bool {
+	t := field.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// setStructField sets field from value like setField, additionally
+// allocating pointers and parsing time.Time fields with the layout from
+// the `time_format` tag of sf (RFC 3339 by default).
+func setStructField(field reflect.Value, sf reflect.StructField, value string) error {
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := setStructField(elem.Elem(), sf, value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	if field.Type() == timeType {
+		layout := sf.Tag.Get("time_format")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			return fmt.Errorf("not a valid time, expected format %s", layout)
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	return setField(field, value)
 }
 
 // setField attempts to set the value of a reflect.Value field based on a string.
+// Types implementing encoding.TextUnmarshaler decode themselves.
 func setField(field reflect.Value, value string) error {
+	if field.CanAddr() {
+		if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(value))
+		}
+	}
+
 	switch field.Kind() {
 	case reflect.String:
 		field.SetString(value)
@@ -91,6 +412,43 @@ func setField(field reflect.Value, value string) error {
 	return nil
 }
 
+// textUnmarshalerType is used to leave types such as time.Time, which
+// decode themselves from text, out of nested struct binding.
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// nestedStruct returns the struct to bind nested keys into when value is a
+// struct or pointer to struct field. A nil pointer is allocated only when
+// present reports that the request carries keys for it.
+func nestedStruct(value reflect.Value, present bool) (reflect.Value, bool) {
+	t := value.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return reflect.Value{}, false
+	}
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			if !present || !value.CanSet() {
+				return reflect.Value{}, false
+			}
+			value.Set(reflect.New(t))
+		}
+		return value.Elem(), true
+	}
+	return value, true
+}
+
+// hasKeyPrefix reports whether any key in data starts with prefix.
+func hasKeyPrefix(data map[string][]string, prefix string) bool {
+	for key := range data {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // bindParams binds path parameters to a struct.
 func bindParams(params map[string]string, v any) error {
 	rv := reflect.ValueOf(v)
@@ -103,20 +461,39 @@ func bindParams(params map[string]string, v any) error {
 		return fmt.Errorf("BindParams requires a pointer to a struct")
 	}
 
+	if fieldErrs := bindParamsValue(params, rv); len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+	return nil
+}
+
+// bindParamsValue binds path parameters into the fields of the struct
+// value rv, recursing into embedded structs.
+func bindParamsValue(params map[string]string, rv reflect.Value) BindFieldErrors {
+	var fieldErrs BindFieldErrors
 	rt := rv.Type()
 	for i := 0; i < rt.NumField(); i++ {
 		field := rt.Field(i)
 		fieldValue := rv.Field(i)
 
-		if !fieldValue.CanSet() {
-			continue
-		}
-
 		// Get the path tag
 		paramName := field.Tag.Get("path")
 		if paramName == "" {
 			paramName = field.Tag.Get("param")
 		}
+		if paramName == "" {
+			if embedded, ok := embeddedStruct(field, fieldValue); ok {
+				fieldErrs = append(fieldErrs, bindParamsValue(params, embedded)...)
+				continue
+			}
+		}
+
+		if !fieldValue.CanSet() {
+			continue
+		}
+		if paramName == "-" {
+			continue
+		}
 		if paramName == "" {
 			// Use field name as fallback (lowercase)
 			paramName = strings.ToLower(field.Name)
@@ -129,17 +506,55 @@ func bindParams(params map[string]string, v any) error {
 		}
 
 		// Set the value
-		if err := setField(fieldValue, value); err != nil {
-			return fmt.Errorf("failed to set field %s: %w", field.Name, err)
+		if err := setStructField(fieldValue, field, value); err != nil {
+			fieldErrs = append(fieldErrs, newBindFieldError(paramName, "path", value, fieldValue, err))
 		}
 	}
+	return fieldErrs
+}
+
+// defaultMaxMultipartMemory is the default memory limit for multipart forms.
+const defaultMaxMultipartMemory = 32 << 20 // 32 MB
+
+// mediaType returns the lower-cased media type of a Content-Type value
+// without its parameters, e.g. "application/json" for
+// "application/json; charset=utf-8". It is "" for an empty value.
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// Fall back to the part before any parameters
+		mt, _, _ = strings.Cut(contentType, ";")
+		mt = strings.ToLower(strings.TrimSpace(mt))
+	}
+	return mt
+}
 
-	return nil
+// isJSONMediaType reports whether mt is JSON, including vendor types
+// with the +json suffix such as application/vnd.api+json.
+func isJSONMediaType(mt string) bool {
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// isFormMediaType reports whether mt is a URL-encoded or multipart form.
+func isFormMediaType(mt string) bool {
+	return mt == "application/x-www-form-urlencoded" || mt == "multipart/form-data"
+}
+
+// parseForm parses URL-encoded and multipart form data. Multipart parts
+// beyond maxMemory bytes are stored in temporary files on disk.
+func parseForm(req *http.Request, maxMemory int64) error {
+	if mediaType(req.Header.Get("Content-Type")) == "multipart/form-data" {
+		if err := req.ParseMultipartForm(maxMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return err
+		}
+		return nil
+	}
+	return req.ParseForm()
 }
 
 // bindForm binds form data to a struct.
-func bindForm(req *http.Request, v any) error {
-	if err := req.ParseForm(); err != nil {
+func bindForm(req *http.Request, v any, maxMemory int64) error {
+	if err := parseForm(req, maxMemory); err != nil {
 		return err
 	}
 
@@ -153,15 +568,22 @@ func bindForm(req *http.Request, v any) error {
 		return fmt.Errorf("BindForm requires a pointer to a struct")
 	}
 
+	if fieldErrs := bindFormValue(req.Form, rv, ""); len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+	return nil
+}
+
+// bindFormValue binds form values into the fields of the struct value rv,
+// recursing into embedded structs and into nested structs using
+// "prefix.name" keys.
+func bindFormValue(form url.Values, rv reflect.Value, prefix string) BindFieldErrors {
+	var fieldErrs BindFieldErrors
 	rt := rv.Type()
 	for i := 0; i < rt.NumField(); i++ {
 		field := rt.Field(i)
 		fieldValue := rv.Field(i)
 
-		if !fieldValue.CanSet() {
-			continue
-		}
-
 		// Get the form tag
 		formName := field.Tag.Get("form")
 		if formName == "" {
@@ -171,21 +593,113 @@ func bindForm(req *http.Request, v any) error {
 				formName = parts[0]
 			}
 		}
-		if formName == "" || formName == "-" {
+		if formName == "" {
+			if embedded, ok := embeddedStruct(field, fieldValue); ok {
+				fieldErrs = append(fieldErrs, bindFormValue(form, embedded, prefix)...)
+			}
+			continue
+		}
+		if formName == "-" {
+			// "-" explicitly excludes the field from binding
+			continue
+		}
+
+		key := prefix + formName
+		if nested, ok := nestedStruct(fieldValue, hasKeyPrefix(form, key+".")); ok {
+			fieldErrs = append(fieldErrs, bindFormValue(form, nested, key+".")...)
+			continue
+		}
+
+		if !fieldValue.CanSet() {
+			continue
+		}
+
+		if isMultiValue(fieldValue) {
+			if values := form[key]; len(values) > 0 {
+				fieldErrs = append(fieldErrs, setSliceField(fieldValue, field, key, "form", values)...)
+			}
 			continue
 		}
 
 		// Get value from form
-		value := req.Form.Get(formName)
+		value := form.Get(key)
 		if value == "" {
 			continue
 		}
 
 		// Set the value
-		if err := setField(fieldValue, value); err != nil {
-			return fmt.Errorf("failed to set field %s: %w", field.Name, err)
+		if err := setStructField(fieldValue, field, value); err != nil {
+			fieldErrs = append(fieldErrs, newBindFieldError(key, "form", value, fieldValue, err))
 		}
 	}
+	return fieldErrs
+}
 
-	return nil
+// bindSources reports which request sources are referenced by the struct
+// tags of t: "path", "query", "header", "cookie", and "body" (json/form).
+func bindSources(t reflect.Type) map[string]bool {
+	sources := make(map[string]bool)
+	collectBindSources(t, sources, make(map[reflect.Type]bool))
+	return sources
+}
+
+// collectBindSources adds the sources referenced by t to sources, including
+// those of embedded structs. seen guards against recursive embedding.
+func collectBindSources(t reflect.Type, sources map[string]bool, seen map[reflect.Type]bool) {
+	if t == nil {
+		return
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			// Promoted fields of embedded structs are bound too
+			collectBindSources(field.Type, sources, seen)
+		}
+
+		tag := field.Tag
+		if hasBindTag(tag, "path") || hasBindTag(tag, "param") {
+			sources["path"] = true
+		}
+		if hasBindTag(tag, "query") {
+			sources["query"] = true
+		}
+		if hasBindTag(tag, "header") {
+			sources["header"] = true
+		}
+		if hasBindTag(tag, "cookie") {
+			sources["cookie"] = true
+		}
+		if hasBindTag(tag, "json") || hasBindTag(tag, "form") {
+			sources["body"] = true
+		}
+	}
+}
+
+// hasBindTag reports whether tag binds the field from the given source.
+// A value of "-" excludes the field and does not count.
+func hasBindTag(tag reflect.StructTag, key string) bool {
+	v := tag.Get(key)
+	return v != "" && v != "-"
+}
+
+// hasBody reports whether the request carries a body.
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody && req.ContentLength != 0
+}
+
+// bindCookies binds request cookies to a struct based on the cookie tag.
+func bindCookies(req *http.Request, v any) error {
+	cookies := make(map[string][]string)
+	for _, cookie := range req.Cookies() {
+		cookies[cookie.Name] = append(cookies[cookie.Name], cookie.Value)
+	}
+	return bindMap(cookies, v, "cookie")
 }
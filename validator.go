@@ -5,8 +5,10 @@ import (
 	"net/url"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // ValidatorFunc is a custom validation function.
@@ -19,22 +21,125 @@ var (
 	alphanumRegex = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
 
 	// customValidators stores user-registered custom validators.
-	customValidators = make(map[string]ValidatorFunc)
+	customValidators = newValidatorRegistry()
+
+	// validationTags lists the struct tag keys read for validation rules,
+	// in order of precedence.
+	validationTags = []string{"validate", "ginji"}
 )
 
 // RegisterValidator registers a custom validator function.
+// It is safe to call concurrently with request handling.
 func RegisterValidator(tag string, fn ValidatorFunc) {
-	customValidators[tag] = fn
+	customValidators.set(tag, fn)
+}
+
+// validatorRegistry is a set of custom validators safe for concurrent use.
+type validatorRegistry struct {
+	mu    sync.RWMutex
+	funcs map[string]ValidatorFunc
+}
+
+func newValidatorRegistry() *validatorRegistry {
+	return &validatorRegistry{funcs: make(map[string]ValidatorFunc)}
+}
+
+func (r *validatorRegistry) set(tag string, fn ValidatorFunc) {
+	r.mu.Lock()
+	r.funcs[tag] = fn
+	r.mu.Unlock()
+}
+
+func (r *validatorRegistry) remove(tag string) {
+	r.mu.Lock()
+	delete(r.funcs, tag)
+	r.mu.Unlock()
+}
+
+// get returns the validator for tag. A nil registry has no validators.
+func (r *validatorRegistry) get(tag string) (ValidatorFunc, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	fn, ok := r.funcs[tag]
+	r.mu.RUnlock()
+	return fn, ok
+}
+
+// SetValidationTag configures the struct tag keys that hold validation rules.
+// Keys are checked in the given order and the first non-empty tag wins.
+// By default "validate" is read first, falling back to "ginji".
+// It should be called during initialization, before handling requests.
+func SetValidationTag(tags ...string) {
+	if len(tags) == 0 {
+		return
+	}
+	validationTags = append([]string(nil), tags...)
+}
+
+// validationTag returns the validation rules for a struct field.
+func validationTag(field reflect.StructField) string {
+	for _, name := range validationTags {
+		if tag := field.Tag.Get(name); tag != "" {
+			return tag
+		}
+	}
+	return ""
+}
+
+// hasRule reports whether the validation tag contains the rule name, with
+// or without a parameter. Rules sharing a prefix, such as required_if for
+// required, do not match.
+func hasRule(tag, name string) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		key, _, _ := strings.Cut(rule, "=")
+		if strings.TrimSpace(key) == name {
+			return true
+		}
+	}
+	return false
 }
 
-// validateStruct checks struct tags for validation rules.
+// RegisterValidator registers a custom validator function scoped to this engine.
+// Engine validators take precedence over globally registered ones and are
+// only used when validating requests handled by this engine.
+// It is safe to call concurrently with request handling.
+func (e *Engine) RegisterValidator(tag string, fn ValidatorFunc) {
+	e.validators.set(tag, fn)
+}
+
+// validationState carries state through a single validation pass.
+type validationState struct {
+	visited    map[uintptr]bool   // guards against circular references
+	validators *validatorRegistry // engine-scoped validators, checked before globals
+}
+
+// validateStruct checks struct tags for validation rules using the global validators.
 // Supported tags: required, email, url, alpha, numeric, alphanum, min, max, len, gt, gte, lt, lte, oneof, regex
 func validateStruct(v any) error {
-	return validateValue(reflect.ValueOf(v), "", make(map[uintptr]bool))
+	return validateStructWith(v, nil)
+}
+
+// validateStructWith validates v, checking the given validators before the global ones.
+func validateStructWith(v any, validators *validatorRegistry) error {
+	state := &validationState{
+		visited:    make(map[uintptr]bool),
+		validators: validators,
+	}
+	return validateValue(reflect.ValueOf(v), "", state)
+}
+
+// lookupValidator returns the custom validator for tag, preferring engine-scoped ones.
+func (s *validationState) lookupValidator(tag string) (ValidatorFunc, bool) {
+	if fn, ok := s.validators.get(tag); ok {
+		return fn, true
+	}
+	return customValidators.get(tag)
 }
 
 // validateValue validates a value recursively.
-func validateValue(val reflect.Value, fieldPath string, visited map[uintptr]bool) error {
+func validateValue(val reflect.Value, fieldPath string, state *validationState) error {
 	if val.Kind() == reflect.Ptr {
 		if val.IsNil() {
 			return nil
@@ -45,34 +150,34 @@ func validateValue(val reflect.Value, fieldPath string, visited map[uintptr]bool
 	// Prevent infinite loops from circular references
 	if val.Kind() == reflect.Struct && val.CanAddr() {
 		addr := val.Addr().Pointer()
-		if visited[addr] {
+		if state.visited[addr] {
 			return nil
 		}
-		visited[addr] = true
-		defer delete(visited, addr)
+		state.visited[addr] = true
+		defer delete(state.visited, addr)
 	}
 
 	switch val.Kind() {
 	case reflect.Struct:
-		return validateStructFields(val, fieldPath, visited)
+		return validateStructFields(val, fieldPath, state)
 	case reflect.Slice, reflect.Array:
-		return validateSliceOrArray(val, fieldPath, visited)
+		return validateSliceOrArray(val, fieldPath, state)
 	case reflect.Map:
-		return validateMap(val, fieldPath, visited)
+		return validateMap(val, fieldPath, state)
 	}
 
 	return nil
 }
 
 // validateStructFields validates all fields in a struct.
-func validateStructFields(val reflect.Value, parentPath string, visited map[uintptr]bool) error {
+func validateStructFields(val reflect.Value, parentPath string, state *validationState) error {
 	t := val.Type()
 	var validationErrors ValidationErrors
 
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 		value := val.Field(i)
-		tag := field.Tag.Get("validate")
+		tag := validationTag(field)
 
 		// Build field path
 		fieldPath := field.Name
@@ -87,13 +192,13 @@ func validateStructFields(val reflect.Value, parentPath string, visited map[uint
 
 		// Validate tags
 		if tag != "" {
-			if errs := validateFieldTags(fieldPath, value, tag); len(errs) > 0 {
+			if errs := validateFieldTags(fieldPath, value, tag, state); len(errs) > 0 {
 				validationErrors = append(validationErrors, errs...)
 			}
 		}
 
 		// Recursively validate nested structs, slices, arrays, maps
-		if err := validateValue(value, fieldPath, visited); err != nil {
+		if err := validateValue(value, fieldPath, state); err != nil {
 			if ve, ok := err.(ValidationErrors); ok {
 				validationErrors = append(validationErrors, ve...)
 			} else {
@@ -109,14 +214,20 @@ func validateStructFields(val reflect.Value, parentPath string, visited map[uint
 }
 
 // validateSliceOrArray validates each element in a slice or array.
-func validateSliceOrArray(val reflect.Value, fieldPath string, visited map[uintptr]bool) error {
+func validateSliceOrArray(val reflect.Value, fieldPath string, state *validationState) error {
+	// Elements of scalar types, e.g. the bytes of a json.RawMessage, carry
+	// no rules, so there is nothing to visit
+	if isScalarKind(val.Type().Elem().Kind()) {
+		return nil
+	}
+
 	var validationErrors ValidationErrors
 
 	for i := 0; i < val.Len(); i++ {
 		elem := val.Index(i)
 		elemPath := fmt.Sprintf("%s[%d]", fieldPath, i)
 
-		if err := validateValue(elem, elemPath, visited); err != nil {
+		if err := validateValue(elem, elemPath, state); err != nil {
 			if ve, ok := err.(ValidationErrors); ok {
 				validationErrors = append(validationErrors, ve...)
 			} else {
@@ -131,15 +242,36 @@ func validateSliceOrArray(val reflect.Value, fieldPath string, visited map[uintp
 	return nil
 }
 
+// isScalarKind reports whether values of kind k cannot contain fields
+// with validation rules.
+func isScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
 // validateMap validates each value in a map.
-func validateMap(val reflect.Value, fieldPath string, visited map[uintptr]bool) error {
+func validateMap(val reflect.Value, fieldPath string, state *validationState) error {
 	var validationErrors ValidationErrors
 
-	for _, key := range val.MapKeys() {
+	// Visit keys in sorted order so errors are reported deterministically
+	keys := val.MapKeys()
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = fmt.Sprintf("%v", key.Interface())
+	}
+	sort.Sort(mapKeysByName{keys, names})
+
+	for i, key := range keys {
 		mapVal := val.MapIndex(key)
-		elemPath := fmt.Sprintf("%s[%v]", fieldPath, key.Interface())
+		elemPath := fmt.Sprintf("%s[%s]", fieldPath, names[i])
 
-		if err := validateValue(mapVal, elemPath, visited); err != nil {
+		if err := validateValue(mapVal, elemPath, state); err != nil {
 			if ve, ok := err.(ValidationErrors); ok {
 				validationErrors = append(validationErrors, ve...)
 			} else {
@@ -154,10 +286,26 @@ func validateMap(val reflect.Value, fieldPath string, visited map[uintptr]bool)
 	return nil
 }
 
+// mapKeysByName sorts map keys by their formatted names.
+type mapKeysByName struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (m mapKeysByName) Len() int           { return len(m.keys) }
+func (m mapKeysByName) Less(i, j int) bool { return m.names[i] < m.names[j] }
+func (m mapKeysByName) Swap(i, j int) {
+	m.keys[i], m.keys[j] = m.keys[j], m.keys[i]
+	m.names[i], m.names[j] = m.names[j], m.names[i]
+}
+
 // validateFieldTags validates a field based on its tags.
-func validateFieldTags(fieldPath string, value reflect.Value, tag string) ValidationErrors {
+// Rules are evaluated in the order they appear in the tag; a rule repeated
+// with the same parameter within the same tag is only evaluated once.
+func validateFieldTags(fieldPath string, value reflect.Value, tag string, state *validationState) ValidationErrors {
 	var errors ValidationErrors
 	rules := strings.Split(tag, ",")
+	seen := make(map[string]bool, len(rules))
 
 	for _, rule := range rules {
 		parts := strings.SplitN(rule, "=", 2)
@@ -166,9 +314,13 @@ func validateFieldTags(fieldPath string, value reflect.Value, tag string) Valida
 		if len(parts) > 1 {
 			param = strings.TrimSpace(parts[1])
 		}
+		if seen[key+"="+param] {
+			continue
+		}
+		seen[key+"="+param] = true
 
 		// Check custom validators first
-		if validator, ok := customValidators[key]; ok {
+		if validator, ok := state.lookupValidator(key); ok {
 			if err := validator(value, param); err != nil {
 				errors = append(errors, ValidationError{
 					Field:   fieldPath,
@@ -548,20 +700,42 @@ func checkLte(fieldName string, v reflect.Value, param string) error {
 }
 
 func checkOneOf(fieldName string, v reflect.Value, param string) error {
-	if v.Kind() != reflect.String {
-		return nil
-	}
-
-	options := strings.Split(param, " ")
-	value := v.String()
+	options := strings.Fields(param)
 
 	for _, option := range options {
-		if value == option {
+		if oneOfMatches(v, option) {
 			return nil
 		}
 	}
 
-	return fmt.Errorf("must be one of: %s", param)
+	switch v.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Errorf("must be one of: %s", param)
+	}
+	return nil
+}
+
+// oneOfMatches reports whether v equals option. Named string types
+// (e.g. `type Status string`) compare by their underlying string and
+// numeric kinds compare by value.
+func oneOfMatches(v reflect.Value, option string) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String() == option
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(option, 10, 64)
+		return err == nil && v.Int() == n
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(option, 10, 64)
+		return err == nil && v.Uint() == n
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(option, v.Type().Bits())
+		return err == nil && v.Float() == f
+	}
+	return false
 }
 
 func isEmptyValue(v reflect.Value) bool {
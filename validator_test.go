@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -209,6 +210,33 @@ func TestValidateOneOf(t *testing.T) {
 	}
 }
 
+func TestValidateOneOfNumericAndNamedTypes(t *testing.T) {
+	type Priority int
+	type Status string
+
+	type Task struct {
+		Level    int      `ginji:"oneof=1 2 3"`
+		Priority Priority `validate:"oneof=10 20"`
+		Status   Status   `validate:"oneof=open closed"`
+		Ratio    float32  `validate:"oneof=0.5 1.5"`
+	}
+
+	valid := Task{Level: 2, Priority: 20, Status: "open", Ratio: 0.5}
+	if err := validateStruct(&valid); err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+
+	invalid := Task{Level: 4, Priority: 15, Status: "archived", Ratio: 2}
+	err := validateStruct(&invalid)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected ValidationErrors, got %T", err)
+	}
+	if len(errs) != 4 {
+		t.Errorf("Expected 4 errors, got %d: %v", len(errs), errs)
+	}
+}
+
 func TestValidateRegex(t *testing.T) {
 	type Phone struct {
 		Number string `validate:"regex=^\\d{3}-\\d{3}-\\d{4}$"`
@@ -342,7 +370,7 @@ func TestCustomValidator(t *testing.T) {
 	}
 
 	// Cleanup
-	delete(customValidators, "even")
+	customValidators.remove("even")
 }
 
 func TestValidationErrorsCollection(t *testing.T) {
@@ -417,3 +445,170 @@ func contains(s, substr string) bool {
 		(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
 			strings.Contains(s, substr)))
 }
+
+func TestValidationTagFallback(t *testing.T) {
+	type Legacy struct {
+		Name string `ginji:"required"`
+	}
+
+	if err := validateStruct(&Legacy{}); err == nil {
+		t.Error("Expected ginji-tagged field to be validated")
+	}
+
+	type Both struct {
+		Name string `validate:"max=3" ginji:"required"`
+	}
+
+	// validate takes precedence, so the empty string passes max=3
+	if err := validateStruct(&Both{}); err != nil {
+		t.Errorf("Expected validate tag to take precedence, got: %v", err)
+	}
+}
+
+func TestSetValidationTag(t *testing.T) {
+	defer SetValidationTag("validate", "ginji")
+
+	type Rules struct {
+		Name string `binding:"required" validate:"max=10"`
+	}
+
+	SetValidationTag("binding")
+	if err := validateStruct(&Rules{}); err == nil {
+		t.Error("Expected binding tag to be used for validation")
+	}
+
+	SetValidationTag("validate")
+	if err := validateStruct(&Rules{}); err != nil {
+		t.Errorf("Expected validate tag to be used, got: %v", err)
+	}
+}
+
+func TestEngineRegisterValidator(t *testing.T) {
+	type Payload struct {
+		Code string `json:"code" validate:"upper"`
+	}
+
+	upper := func(value reflect.Value, param string) error {
+		if value.String() != strings.ToUpper(value.String()) {
+			return fmt.Errorf("must be upper case")
+		}
+		return nil
+	}
+
+	strict := New()
+	strict.RegisterValidator("upper", upper)
+	other := New()
+
+	handler := func(c *Context) error {
+		var p Payload
+		if err := c.BindJSON(&p); err != nil {
+			c.AbortWithError(StatusUnprocessableEntity, err)
+			return nil
+		}
+		return c.Text(StatusOK, p.Code)
+	}
+	strict.Post("/", handler)
+	other.Post("/", handler)
+
+	w := PerformRequest(strict, "POST", "/", strings.NewReader(`{"code":"abc"}`))
+	if w.Code != StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 from engine validator, got %d", w.Code)
+	}
+
+	// Validators registered on one engine must not leak into another
+	w = PerformRequest(other, "POST", "/", strings.NewReader(`{"code":"abc"}`))
+	if w.Code != StatusOK {
+		t.Errorf("Expected status 200 without engine validator, got %d", w.Code)
+	}
+
+	if _, ok := customValidators.get("upper"); ok {
+		t.Error("Expected engine validator not to be registered globally")
+	}
+}
+
+// TestRegisterValidatorConcurrent is meaningful under `go test -race`.
+func TestRegisterValidatorConcurrent(t *testing.T) {
+	defer customValidators.remove("concurrent")
+
+	type Item struct {
+		Name string `validate:"concurrent"`
+	}
+
+	app := New()
+	noop := func(value reflect.Value, param string) error { return nil }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterValidator("concurrent", noop)
+			app.RegisterValidator("concurrent", noop)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = validateStructWith(&Item{Name: "x"}, app.validators)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestValidationErrorsOrder(t *testing.T) {
+	type Address struct {
+		City string `validate:"required"`
+		Zip  string `validate:"len=5"`
+	}
+	type Form struct {
+		Name    string    `validate:"required,min=2"`
+		Address Address   `validate:"required"`
+		Tags    []Address `validate:"min=1"`
+		Labels  map[string]Address
+		Age     int `validate:"min=18,min=18"`
+	}
+
+	form := Form{
+		Address: Address{Zip: "1"},
+		Labels: map[string]Address{
+			"b": {City: "x"},
+			"a": {City: "y"},
+		},
+		Age: 10,
+	}
+
+	for i := 0; i < 5; i++ {
+		err := validateStruct(&form)
+		errs, ok := err.(ValidationErrors)
+		if !ok {
+			t.Fatalf("Expected ValidationErrors, got %T", err)
+		}
+
+		var got []string
+		for _, e := range errs {
+			got = append(got, e.Field+":"+e.Tag)
+		}
+		expected := []string{
+			"Name:required",
+			"Name:min",
+			"Address.City:required",
+			"Address.Zip:len",
+			"Tags:min",
+			"Labels[a].Zip:len",
+			"Labels[b].Zip:len",
+			"Age:min",
+		}
+		if strings.Join(got, " ") != strings.Join(expected, " ") {
+			t.Fatalf("Expected order %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestRepeatedRuleWithDifferentParams(t *testing.T) {
+	type Form struct {
+		Code string `validate:"min=2,min=5,min=2"`
+	}
+
+	errs, ok := validateStruct(&Form{Code: "abc"}).(ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].Tag != "min" {
+		t.Errorf("Expected only min=5 to fail, got %v", errs)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"log" // Added for logging errors
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -40,6 +41,28 @@ type WebSocketConn struct {
 	writeMu   sync.Mutex
 	closed    bool
 	closeOnce sync.Once
+	data      map[string]any // application metadata, guarded by mu
+
+	writeTimeout  time.Duration // deadline applied to each write, 0 for none
+	writeDeadline time.Time     // explicit deadline from SetWriteDeadline, guarded by writeMu
+}
+
+// SetData attaches a metadata value, such as a user ID or room, to the connection.
+func (ws *WebSocketConn) SetData(key string, val any) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.data == nil {
+		ws.data = make(map[string]any)
+	}
+	ws.data[key] = val
+}
+
+// GetData returns the metadata value stored under key.
+func (ws *WebSocketConn) GetData(key string) (any, bool) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	val, ok := ws.data[key]
+	return val, ok
 }
 
 // WebSocketConfig defines configuration for WebSocket upgrade.
@@ -162,13 +185,31 @@ func (u *WebSocketUpgrader) Upgrade(c *Context) (*WebSocketConn, error) {
 	}
 
 	return &WebSocketConn{
-		conn:   conn,
-		closed: false,
+		conn:         conn,
+		closed:       false,
+		writeTimeout: u.config.WriteTimeout,
 	}, nil
 }
 
+// SetWriteDeadline sets an absolute deadline for writes, replacing the
+// per-write WriteTimeout of the upgrader config. A zero value restores
+// the WriteTimeout. A write that times out closes the connection, since a
+// partially written frame leaves it unusable.
+func (ws *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	ws.writeDeadline = t
+	return ws.conn.SetWriteDeadline(t)
+}
+
 // WriteMessage writes a message to the WebSocket connection.
 func (ws *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	return ws.writeMessage(messageType, data, ws.writeTimeout)
+}
+
+// writeMessage writes a message, giving up after timeout unless an
+// explicit write deadline is set.
+func (ws *WebSocketConn) writeMessage(messageType int, data []byte, timeout time.Duration) error {
 	ws.writeMu.Lock()
 	defer ws.writeMu.Unlock()
 
@@ -188,7 +229,18 @@ func (ws *WebSocketConn) WriteMessage(messageType int, data []byte) error {
 	frame[1] = byte(len(data))          // Payload length (simplified)
 	copy(frame[2:], data)
 
+	deadline := ws.writeDeadline
+	if deadline.IsZero() && timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := ws.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
 	_, err := ws.conn.Write(frame)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		_ = ws.Close()
+	}
 	return err
 }
 
@@ -301,15 +353,28 @@ type Hub struct {
 	register    chan *WebSocketConn
 	unregister  chan *WebSocketConn
 	mu          sync.RWMutex
+
+	writeTimeout time.Duration // deadline for each broadcast write
 }
 
 // NewHub creates a new Hub.
 func NewHub() *Hub {
+	return NewHubWithConfig(DefaultWebSocketConfig())
+}
+
+// NewHubWithConfig creates a new Hub using the WriteTimeout of config
+// for broadcast writes, so that a stuck client cannot pin a goroutine.
+// Connections whose write times out are closed and unregistered.
+func NewHubWithConfig(config WebSocketConfig) *Hub {
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = defaultWriteTimeout
+	}
 	return &Hub{
-		connections: make(map[*WebSocketConn]bool),
-		broadcast:   make(chan []byte, 256),
-		register:    make(chan *WebSocketConn),
-		unregister:  make(chan *WebSocketConn),
+		connections:  make(map[*WebSocketConn]bool),
+		broadcast:    make(chan []byte, 256),
+		register:     make(chan *WebSocketConn),
+		unregister:   make(chan *WebSocketConn),
+		writeTimeout: config.WriteTimeout,
 	}
 }
 
@@ -333,11 +398,11 @@ func (h *Hub) Run() {
 		case message := <-h.broadcast:
 			h.mu.RLock()
 			for conn := range h.connections {
-				go func(c *WebSocketConn) {
-					if err := c.WriteMessage(TextMessage, message); err != nil {
-						h.unregister <- c
+				safeGo(nil, func() {
+					if err := conn.writeMessage(TextMessage, message, h.writeTimeout); err != nil {
+						h.unregister <- conn
 					}
-				}(conn)
+				})
 			}
 			h.mu.RUnlock()
 		}
@@ -366,6 +431,21 @@ func (h *Hub) Count() int {
 	return len(h.connections)
 }
 
+// ForEach calls fn for each registered connection. The connection set is
+// copied first, so fn may call back into the hub (e.g. Unregister).
+func (h *Hub) ForEach(fn func(*WebSocketConn)) {
+	h.mu.RLock()
+	conns := make([]*WebSocketConn, 0, len(h.connections))
+	for conn := range h.connections {
+		conns = append(conns, conn)
+	}
+	h.mu.RUnlock()
+
+	for _, conn := range conns {
+		fn(conn)
+	}
+}
+
 // checkSameOrigin implements the default same-origin policy for WebSocket connections.
 func checkSameOrigin(c *Context) bool {
 	origin := c.Header("Origin")
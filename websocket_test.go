@@ -0,0 +1,114 @@
+package ginji
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWebSocketConnData(t *testing.T) {
+	ws := &WebSocketConn{}
+
+	if _, ok := ws.GetData("user"); ok {
+		t.Error("Expected no data on a new connection")
+	}
+
+	ws.SetData("user", "alice")
+	ws.SetData("room", 42)
+
+	if val, ok := ws.GetData("user"); !ok || val != "alice" {
+		t.Errorf("Expected user alice, got %v", val)
+	}
+	if val, ok := ws.GetData("room"); !ok || val != 42 {
+		t.Errorf("Expected room 42, got %v", val)
+	}
+}
+
+func TestHubForEach(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	newConn := func(user string) *WebSocketConn {
+		server, client := net.Pipe()
+		t.Cleanup(func() { _ = client.Close() })
+		ws := &WebSocketConn{conn: server}
+		ws.SetData("user", user)
+		hub.Register(ws)
+		return ws
+	}
+	newConn("alice")
+	newConn("alice")
+	newConn("bob")
+
+	// Unregistering from the callback must not deadlock
+	hub.ForEach(func(ws *WebSocketConn) {
+		if user, _ := ws.GetData("user"); user == "alice" {
+			hub.Unregister(ws)
+		}
+	})
+
+	// Run handles one operation at a time, so once this no-op unregister is
+	// accepted, the unregistrations above have been applied.
+	hub.Unregister(&WebSocketConn{})
+
+	if hub.Count() != 1 {
+		t.Errorf("Expected 1 remaining connection, got %d", hub.Count())
+	}
+	hub.ForEach(func(ws *WebSocketConn) {
+		if user, _ := ws.GetData("user"); user != "bob" {
+			t.Errorf("Expected only bob to remain, got %v", user)
+		}
+	})
+}
+
+func TestWebSocketWriteTimeout(t *testing.T) {
+	server, client := net.Pipe()
+	t.Cleanup(func() { _ = client.Close() })
+	ws := &WebSocketConn{conn: server, writeTimeout: 20 * time.Millisecond}
+
+	// Nobody reads from the pipe, so the write blocks until the deadline
+	if err := ws.WriteMessage(TextMessage, []byte("hi")); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+	if err := ws.WriteMessage(TextMessage, []byte("hi")); err == nil {
+		t.Error("Expected connection to be closed after a write timeout")
+	}
+
+	// An explicit deadline overrides the timeout
+	server, client = net.Pipe()
+	t.Cleanup(func() { _ = client.Close() })
+	ws = &WebSocketConn{conn: server}
+	go func() { _, _ = io.Copy(io.Discard, client) }()
+	if err := ws.SetWriteDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.WriteMessage(TextMessage, []byte("hi")); err != nil {
+		t.Errorf("Expected write before the deadline to succeed, got %v", err)
+	}
+	_ = ws.SetWriteDeadline(time.Now().Add(-time.Second))
+	if err := ws.WriteMessage(TextMessage, []byte("hi")); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Expected write after the deadline to fail, got %v", err)
+	}
+}
+
+func TestHubBroadcastDropsStuckClient(t *testing.T) {
+	hub := NewHubWithConfig(WebSocketConfig{WriteTimeout: 20 * time.Millisecond})
+	go hub.Run()
+
+	server, client := net.Pipe()
+	t.Cleanup(func() { _ = client.Close() })
+	hub.Register(&WebSocketConn{conn: server})
+
+	hub.Broadcast([]byte("hello"))
+
+	deadline := time.Now().Add(time.Second)
+	for hub.Count() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected stuck client to be unregistered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}